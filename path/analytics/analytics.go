@@ -0,0 +1,305 @@
+// Package analytics aggregates the attack history of a Path account into statistics, such as per-host counts and
+// durations, the most targeted hosts, the distribution of attack vectors, histograms over time and trends between two
+// periods. It also correlates attacks with announcements and diversions into incident timelines. All functions operate
+// on the types returned by the client's history and diversion calls.
+package analytics
+
+import (
+	"net"
+	"sort"
+	"time"
+
+	"github.com/path-network/go-path"
+)
+
+// Summary aggregates a group of attacks
+type Summary struct {
+	// Count is the number of attacks, including ongoing ones
+	Count int
+	// Ongoing is the number of attacks which have started but not ended yet
+	Ongoing int
+	// Undated is the number of attacks without a start. They are included in Count and the peaks, but not in Ongoing,
+	// the durations, First or Last, as they cannot be placed in time
+	Undated int
+	// TotalDuration and MeanDuration only account for attacks which have started and ended
+	TotalDuration time.Duration
+	MeanDuration  time.Duration
+	MaxPeakBPS    path.BPS
+	MaxPeakPPS    path.PPS
+	// First and Last are the start times of the earliest and latest attack
+	First time.Time
+	Last  time.Time
+}
+
+// Summarize aggregates the given attacks
+func Summarize(attacks []path.AttackDetails) Summary {
+	var summary Summary
+	for _, attack := range attacks {
+		summary.add(attack)
+	}
+
+	return summary.finish()
+}
+
+// add accounts for an attack in the summary. finish must be called once all attacks were added
+func (summary *Summary) add(attack path.AttackDetails) {
+	summary.Count++
+	summary.MaxPeakBPS = path.MaxBPS(summary.MaxPeakBPS, attack.PeakBPS.Value)
+	summary.MaxPeakPPS = path.MaxPPS(summary.MaxPeakPPS, attack.PeakPPS.Value)
+
+	if attack.Start.IsZero() {
+		summary.Undated++
+		return
+	}
+
+	if attack.End.IsZero() {
+		summary.Ongoing++
+	} else {
+		summary.TotalDuration += attack.End.Sub(attack.Start.Time)
+	}
+
+	if summary.First.IsZero() || attack.Start.Before(summary.First) {
+		summary.First = attack.Start.Time
+	}
+
+	if attack.Start.After(summary.Last) {
+		summary.Last = attack.Start.Time
+	}
+}
+
+// finish computes the fields of the summary which depend on every attack
+func (summary Summary) finish() Summary {
+	if ended := summary.Count - summary.Ongoing - summary.Undated; ended > 0 {
+		summary.MeanDuration = summary.TotalDuration / time.Duration(ended)
+	}
+
+	return summary
+}
+
+// groupBy summarizes the attacks per key. Attacks for which keys returns no key are left out
+func groupBy(attacks []path.AttackDetails, keys func(path.AttackDetails) []string) map[string]Summary {
+	summaries := map[string]*Summary{}
+	for _, attack := range attacks {
+		for _, key := range keys(attack) {
+			if summaries[key] == nil {
+				summaries[key] = &Summary{}
+			}
+			summaries[key].add(attack)
+		}
+	}
+
+	finished := make(map[string]Summary, len(summaries))
+	for key, summary := range summaries {
+		finished[key] = summary.finish()
+	}
+
+	return finished
+}
+
+// ByHost summarizes the attacks per targeted host
+func ByHost(attacks []path.AttackDetails) map[string]Summary {
+	return groupBy(attacks, func(attack path.AttackDetails) []string {
+		return []string{attack.Host}
+	})
+}
+
+// ByPrefix summarizes the attacks per prefix containing the targeted host, e.g. per /24 for IPv4 and per /48 for IPv6.
+// Attacks against hosts which are not IP addresses are left out
+func ByPrefix(attacks []path.AttackDetails, ipv4PrefixLength, ipv6PrefixLength int) map[string]Summary {
+	return groupBy(attacks, func(attack path.AttackDetails) []string {
+		ip := net.ParseIP(attack.Host)
+		if ip == nil {
+			return nil
+		}
+
+		network := net.IPNet{IP: ip.Mask(net.CIDRMask(ipv6PrefixLength, 128)), Mask: net.CIDRMask(ipv6PrefixLength, 128)}
+		if ip4 := ip.To4(); ip4 != nil {
+			network = net.IPNet{IP: ip4.Mask(net.CIDRMask(ipv4PrefixLength, 32)), Mask: net.CIDRMask(ipv4PrefixLength, 32)}
+		}
+
+		return []string{network.String()}
+	})
+}
+
+// ByVector summarizes the attacks per attack vector recognized in their reason. An attack combining several vectors is
+// counted once for each of them
+func ByVector(attacks []path.AttackDetails) map[path.AttackVector]Summary {
+	byKey := groupBy(attacks, func(attack path.AttackDetails) []string {
+		var keys []string
+		for _, vector := range attack.Reason.Vectors() {
+			keys = append(keys, string(vector))
+		}
+		return keys
+	})
+
+	summaries := make(map[path.AttackVector]Summary, len(byKey))
+	for key, summary := range byKey {
+		summaries[path.AttackVector(key)] = summary
+	}
+
+	return summaries
+}
+
+// Target is a host ranked by the peaks of the attacks against it
+type Target struct {
+	Host    string
+	Summary Summary
+}
+
+// TopByPeakBPS returns up to n hosts with the highest attack peak in bits per second, highest first
+func TopByPeakBPS(attacks []path.AttackDetails, n int) []Target {
+	return top(attacks, n, func(summary Summary) uint64 { return uint64(summary.MaxPeakBPS) })
+}
+
+// TopByPeakPPS returns up to n hosts with the highest attack peak in packets per second, highest first
+func TopByPeakPPS(attacks []path.AttackDetails, n int) []Target {
+	return top(attacks, n, func(summary Summary) uint64 { return uint64(summary.MaxPeakPPS) })
+}
+
+// top ranks the hosts by the given peak. Ties are broken by host so that the ranking is stable
+func top(attacks []path.AttackDetails, n int, peak func(Summary) uint64) []Target {
+	var targets []Target
+	for host, summary := range ByHost(attacks) {
+		targets = append(targets, Target{Host: host, Summary: summary})
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		if peak(targets[i].Summary) != peak(targets[j].Summary) {
+			return peak(targets[i].Summary) > peak(targets[j].Summary)
+		}
+		return targets[i].Host < targets[j].Host
+	})
+
+	if n >= 0 && len(targets) > n {
+		targets = targets[:n]
+	}
+
+	return targets
+}
+
+// Bucket is a period of a histogram along with the number of attacks which started within it
+type Bucket struct {
+	Start time.Time
+	Count int
+}
+
+// Hourly counts the attacks started in each hour, from the hour of the earliest attack to that of the latest. Hours
+// without attacks are included with a count of zero
+func Hourly(attacks []path.AttackDetails, location *time.Location) []Bucket {
+	return histogram(attacks, location, func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	}, func(t time.Time) time.Time {
+		return t.Add(time.Hour)
+	})
+}
+
+// Daily counts the attacks started on each calendar day in the given time zone, from the day of the earliest attack to
+// that of the latest. Days without attacks are included with a count of zero
+func Daily(attacks []path.AttackDetails, location *time.Location) []Bucket {
+	return histogram(attacks, location, func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}, func(t time.Time) time.Time {
+		return t.AddDate(0, 0, 1)
+	})
+}
+
+// histogram counts the attacks per bucket. truncate maps a time to the start of its bucket, and next returns the start
+// of the bucket following the one starting at the given time. Attacks without a start are left out, as they would
+// stretch the histogram back to year 1
+func histogram(attacks []path.AttackDetails, location *time.Location, truncate, next func(time.Time) time.Time) []Bucket {
+	if location == nil {
+		location = time.UTC
+	}
+
+	counts := map[time.Time]int{}
+	var first, last time.Time
+	for _, attack := range attacks {
+		if attack.Start.IsZero() {
+			continue
+		}
+
+		start := truncate(attack.Start.In(location))
+		counts[start]++
+
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if last.IsZero() || start.After(last) {
+			last = start
+		}
+	}
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	var buckets []Bucket
+	for start := first; !start.After(last); start = next(start) {
+		buckets = append(buckets, Bucket{Start: start, Count: counts[start]})
+	}
+
+	return buckets
+}
+
+// HourOfDay counts the attacks by the hour of the day, in the given time zone, at which they started. Attacks without a
+// start are left out
+func HourOfDay(attacks []path.AttackDetails, location *time.Location) [24]int {
+	if location == nil {
+		location = time.UTC
+	}
+
+	var counts [24]int
+	for _, attack := range attacks {
+		if attack.Start.IsZero() {
+			continue
+		}
+
+		counts[attack.Start.In(location).Hour()]++
+	}
+
+	return counts
+}
+
+// Between returns the attacks which started within [start, end)
+func Between(attacks []path.AttackDetails, start, end time.Time) []path.AttackDetails {
+	var selected []path.AttackDetails
+	for _, attack := range attacks {
+		if !attack.Start.Before(start) && attack.Start.Before(end) {
+			selected = append(selected, attack)
+		}
+	}
+
+	return selected
+}
+
+// Trend compares the attacks of two periods
+type Trend struct {
+	Previous Summary
+	Current  Summary
+	// CountDelta is the change in the number of attacks, and CountChange the same change relative to the previous
+	// period (e.g. 0.5 for an increase by half). CountChange is zero if there were no attacks in the previous period
+	CountDelta         int
+	CountChange        float64
+	TotalDurationDelta time.Duration
+	MeanDurationDelta  time.Duration
+	MaxPeakBPSDelta    int64
+	MaxPeakPPSDelta    int64
+}
+
+// Compare computes the changes from the attacks of a previous period to those of the current one. Use Between to split
+// a history into periods, e.g. two consecutive weeks
+func Compare(previous, current []path.AttackDetails) Trend {
+	trend := Trend{Previous: Summarize(previous), Current: Summarize(current)}
+
+	trend.CountDelta = trend.Current.Count - trend.Previous.Count
+	if trend.Previous.Count > 0 {
+		trend.CountChange = float64(trend.CountDelta) / float64(trend.Previous.Count)
+	}
+
+	trend.TotalDurationDelta = trend.Current.TotalDuration - trend.Previous.TotalDuration
+	trend.MeanDurationDelta = trend.Current.MeanDuration - trend.Previous.MeanDuration
+	trend.MaxPeakBPSDelta = trend.Current.MaxPeakBPS.Delta(trend.Previous.MaxPeakBPS)
+	trend.MaxPeakPPSDelta = trend.Current.MaxPeakPPS.Delta(trend.Previous.MaxPeakPPS)
+
+	return trend
+}
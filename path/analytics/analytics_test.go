@@ -0,0 +1,148 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/path-network/go-path"
+)
+
+// attack builds the details of an attack starting at the given hour of 2020-01-01 UTC
+func attack(host string, reason path.AttackReason, startHour, hours int, peakBPS path.BPS, peakPPS path.PPS) path.AttackDetails {
+	start := time.Date(2020, 1, 1, startHour, 0, 0, 0, time.UTC)
+
+	details := path.AttackDetails{
+		Host:    host,
+		Reason:  reason,
+		Start:   path.NewTimestamp(start),
+		PeakBPS: path.BPSPeak{Value: peakBPS},
+		PeakPPS: path.PPSPeak{Value: peakPPS},
+	}
+	if hours > 0 {
+		details.End = path.NewTimestamp(start.Add(time.Duration(hours) * time.Hour))
+	}
+
+	return details
+}
+
+var testAttacks = []path.AttackDetails{
+	attack("203.0.113.5", "UDP flood", 0, 1, 1000, 10),
+	attack("203.0.113.5", "SYN flood", 2, 3, 3000, 5),
+	attack("203.0.113.9", "DNS amplification, SYN flood", 2, 0, 2000, 40),
+	attack("198.51.100.1", "UDP flood", 26, 2, 500, 1),
+}
+
+// TestByHost ensures that attacks are counted and their durations and peaks aggregated per host
+func TestByHost(t *testing.T) {
+	got := ByHost(testAttacks)["203.0.113.5"]
+
+	expected := Summary{
+		Count:         2,
+		TotalDuration: 4 * time.Hour,
+		MeanDuration:  2 * time.Hour,
+		MaxPeakBPS:    3000,
+		MaxPeakPPS:    10,
+		First:         time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Last:          time.Date(2020, 1, 1, 2, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v\n", expected, got)
+	}
+
+	if ongoing := ByHost(testAttacks)["203.0.113.9"]; ongoing.Ongoing != 1 || ongoing.MeanDuration != 0 {
+		t.Errorf("Expected one ongoing attack without a duration, got %+v\n", ongoing)
+	}
+}
+
+// TestByPrefixAndVector ensures that attacks are grouped by prefix and by every vector they combine
+func TestByPrefixAndVector(t *testing.T) {
+	prefixes := ByPrefix(testAttacks, 24, 48)
+	if len(prefixes) != 2 || prefixes["203.0.113.0/24"].Count != 3 || prefixes["198.51.100.0/24"].Count != 1 {
+		t.Errorf("Unexpected prefix summaries: %+v\n", prefixes)
+	}
+
+	vectors := ByVector(testAttacks)
+	if vectors[path.VectorSYNFlood].Count != 2 || vectors[path.VectorUDPFlood].Count != 2 ||
+		vectors[path.VectorDNSAmplification].Count != 1 {
+		t.Errorf("Unexpected vector summaries: %+v\n", vectors)
+	}
+}
+
+// TestTopAndHistograms ensures that targets are ranked by their peaks and that histograms include empty buckets
+func TestTopAndHistograms(t *testing.T) {
+	var hosts []string
+	for _, target := range TopByPeakPPS(testAttacks, 2) {
+		hosts = append(hosts, target.Host)
+	}
+
+	if expected := []string{"203.0.113.9", "203.0.113.5"}; !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("Expected %v, got %v\n", expected, hosts)
+	}
+
+	hourly := Hourly(testAttacks, nil)
+	if len(hourly) != 27 || hourly[0].Count != 1 || hourly[1].Count != 0 || hourly[2].Count != 2 {
+		t.Errorf("Unexpected hourly histogram: %+v\n", hourly)
+	}
+
+	daily := Daily(testAttacks, nil)
+	if len(daily) != 2 || daily[0].Count != 3 || daily[1].Count != 1 {
+		t.Errorf("Unexpected daily histogram: %+v\n", daily)
+	}
+}
+
+// TestHistogramZeroStart ensures that attacks without a start are left out of histograms instead of stretching them
+// back to year 1
+func TestHistogramZeroStart(t *testing.T) {
+	attacks := append([]path.AttackDetails{{Host: "203.0.113.7", Reason: "UDP flood"}}, testAttacks...)
+
+	if hourly := Hourly(attacks, nil); len(hourly) != 27 || !hourly[0].Start.Equal(testAttacks[0].Start.Time) {
+		t.Errorf("Unexpected hourly histogram: %+v\n", hourly)
+	}
+
+	if daily := Daily(attacks[:1], nil); daily != nil {
+		t.Errorf("Expected %+v, got %+v\n", nil, daily)
+	}
+}
+
+// TestSummaryZeroStart ensures that attacks without a start are counted apart, without a duration or a start time
+func TestSummaryZeroStart(t *testing.T) {
+	undated := path.AttackDetails{Host: "203.0.113.5", Reason: "UDP flood", End: testAttacks[0].End,
+		PeakBPS: path.BPSPeak{Value: 8000}}
+	got := Summarize(append([]path.AttackDetails{undated}, testAttacks[:2]...))
+
+	expected := Summary{
+		Count:         3,
+		Undated:       1,
+		TotalDuration: 4 * time.Hour,
+		MeanDuration:  2 * time.Hour,
+		MaxPeakBPS:    8000,
+		MaxPeakPPS:    10,
+		First:         time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Last:          time.Date(2020, 1, 1, 2, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v\n", expected, got)
+	}
+}
+
+// TestHourOfDayZeroStart ensures that attacks without a start are not counted at midnight
+func TestHourOfDayZeroStart(t *testing.T) {
+	attacks := append([]path.AttackDetails{{Host: "203.0.113.7", Reason: "UDP flood"}}, testAttacks...)
+
+	var expected [24]int
+	expected[0], expected[2] = 1, 3
+	if got := HourOfDay(attacks, nil); got != expected {
+		t.Errorf("Expected %+v, got %+v\n", expected, got)
+	}
+}
+
+// TestCompare ensures that trend deltas are computed between two periods
+func TestCompare(t *testing.T) {
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	trend := Compare(Between(testAttacks, day, day.Add(time.Hour)), Between(testAttacks, day.Add(time.Hour), day.AddDate(0, 0, 2)))
+
+	if trend.CountDelta != 2 || trend.CountChange != 2 || trend.MaxPeakBPSDelta != 2000 {
+		t.Errorf("Unexpected trend: %+v\n", trend)
+	}
+}
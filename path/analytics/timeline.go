@@ -0,0 +1,246 @@
+package analytics
+
+import (
+	"net"
+	"sort"
+	"time"
+
+	"github.com/path-network/go-path"
+)
+
+// TimelineEventType identifies a moment in the timeline of an incident
+type TimelineEventType string
+
+// Moments recorded in the timeline of an incident
+const (
+	AttackStarted       TimelineEventType = "attack_started"
+	PeakBPSReached      TimelineEventType = "peak_bps"
+	PeakPPSReached      TimelineEventType = "peak_pps"
+	AttackEnded         TimelineEventType = "attack_ended"
+	AnnouncementStarted TimelineEventType = "announcement_started"
+	AnnouncementEnded   TimelineEventType = "announcement_ended"
+	// The host is currently reported as under attack within a diverted subnet
+	HostDiverted TimelineEventType = "host_diverted"
+)
+
+// TimelineEvent is a single moment in the timeline of an incident
+type TimelineEvent struct {
+	Time time.Time
+	Type TimelineEventType
+	// Subject is the attacked host for attack events, and the network or subnet for announcement and diversion events
+	Subject string
+	Reason  path.AttackReason
+	// Value holds the peak for PeakBPSReached events, in bits per second, and for PeakPPSReached events, in packets per
+	// second
+	Value uint64
+}
+
+// Incident groups the overlapping attacks against a host together with the announcements and diversion that mitigated
+// them
+type Incident struct {
+	Host string
+	// Start is the start of the earliest attack, and End the end of the latest one. End is the zero time while any of
+	// the attacks is ongoing
+	Start         time.Time
+	End           time.Time
+	Attacks       []path.AttackDetails
+	Announcements []path.AnnouncementDetails
+	// Diversion is the current diversion of the subnet containing the host. It is only set for incidents which are
+	// ongoing or whose announcements are still active, as the diversions only reflect the current state
+	Diversion *path.Diversion
+	// Timeline holds every moment of the incident in chronological order
+	Timeline []TimelineEvent
+	// TimeToMitigate is the time from the start of the incident to the start of the first announcement covering the
+	// host. It is negative if the host was announced ahead of the attack, and only meaningful if Mitigated is true
+	TimeToMitigate time.Duration
+	Mitigated      bool
+	// TimeToWithdraw is the time from the end of the incident to the end of the last announcement covering the host. It
+	// is only meaningful if Withdrawn is true, which requires the incident and all of its announcements to have ended
+	TimeToWithdraw time.Duration
+	Withdrawn      bool
+}
+
+// Correlate builds the incidents of the attack history. Attacks against the same host whose durations overlap are
+// merged into a single incident. Announcements are attached to an incident if their network contains the host and they
+// were active at any point during the incident. Incidents are ordered by start time. Attacks without a start are left
+// out, as they cannot be placed on a timeline
+func Correlate(attacks path.AttackHistory, announcements path.AnnouncementHistory, diversions path.Diversions) []Incident {
+	var sorted []path.AttackDetails
+	for _, attack := range attacks.AttackHistory {
+		if !attack.Start.IsZero() {
+			sorted = append(sorted, attack)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start.Time)
+	})
+
+	// Merge overlapping attacks per host, keeping track of the incident each host is currently in
+	var incidents []*Incident
+	open := map[string]*Incident{}
+	for _, attack := range sorted {
+		incident := open[attack.Host]
+		if incident == nil || (!incident.End.IsZero() && attack.Start.After(incident.End)) {
+			incident = &Incident{Host: attack.Host, Start: attack.Start.Time, End: attack.End.Time}
+			incidents = append(incidents, incident)
+			open[attack.Host] = incident
+		} else if incident.End.IsZero() || attack.End.IsZero() {
+			incident.End = time.Time{}
+		} else if attack.End.After(incident.End) {
+			incident.End = attack.End.Time
+		}
+
+		incident.Attacks = append(incident.Attacks, attack)
+	}
+
+	correlated := make([]Incident, len(incidents))
+	for i, incident := range incidents {
+		incident.attachAnnouncements(announcements)
+		incident.attachDiversion(diversions)
+		incident.buildTimeline()
+		incident.computeMetrics()
+
+		correlated[i] = *incident
+	}
+
+	return correlated
+}
+
+// attachAnnouncements adds the announcements covering the host during the incident
+func (incident *Incident) attachAnnouncements(announcements path.AnnouncementHistory) {
+	ip := net.ParseIP(incident.Host)
+	if ip == nil {
+		return
+	}
+
+	for _, announcement := range announcements.AnnouncementHistory {
+		if !networkContains(announcement.Net, ip) {
+			continue
+		}
+
+		startsInTime := incident.End.IsZero() || !announcement.Start.After(incident.End)
+		endsInTime := announcement.End.IsZero() || !announcement.End.Before(incident.Start)
+		if startsInTime && endsInTime {
+			incident.Announcements = append(incident.Announcements, announcement)
+		}
+	}
+}
+
+// attachDiversion adds the current diversion of the subnet containing the host, if the incident is still relevant to
+// the current state
+func (incident *Incident) attachDiversion(diversions path.Diversions) {
+	current := incident.End.IsZero()
+	for _, announcement := range incident.Announcements {
+		current = current || announcement.Active()
+	}
+
+	if !current {
+		return
+	}
+
+	ip := net.ParseIP(incident.Host)
+	if ip == nil {
+		return
+	}
+
+	for i, diversion := range diversions.Diversions {
+		if networkContains(diversion.Subnet, ip) {
+			incident.Diversion = &diversions.Diversions[i]
+			return
+		}
+	}
+}
+
+// timelineOrder breaks ties between events happening at the same time
+var timelineOrder = map[TimelineEventType]int{
+	AttackStarted:       0,
+	AnnouncementStarted: 1,
+	HostDiverted:        2,
+	PeakBPSReached:      3,
+	PeakPPSReached:      4,
+	AttackEnded:         5,
+	AnnouncementEnded:   6,
+}
+
+// buildTimeline collects the moments of the attacks, announcements and diversion in chronological order
+func (incident *Incident) buildTimeline() {
+	add := func(t time.Time, eventType TimelineEventType, subject string, reason path.AttackReason, value uint64) {
+		if !t.IsZero() {
+			incident.Timeline = append(incident.Timeline, TimelineEvent{
+				Time: t, Type: eventType, Subject: subject, Reason: reason, Value: value,
+			})
+		}
+	}
+
+	for _, attack := range incident.Attacks {
+		add(attack.Start.Time, AttackStarted, attack.Host, attack.Reason, 0)
+		add(attack.PeakBPS.Timestamp.Time, PeakBPSReached, attack.Host, attack.Reason, uint64(attack.PeakBPS.Value))
+		add(attack.PeakPPS.Timestamp.Time, PeakPPSReached, attack.Host, attack.Reason, uint64(attack.PeakPPS.Value))
+		add(attack.End.Time, AttackEnded, attack.Host, attack.Reason, 0)
+	}
+
+	for _, announcement := range incident.Announcements {
+		add(announcement.Start.Time, AnnouncementStarted, announcement.Net, announcement.Reason, 0)
+		add(announcement.End.Time, AnnouncementEnded, announcement.Net, announcement.Reason, 0)
+	}
+
+	if incident.Diversion != nil {
+		for _, underAttack := range incident.Diversion.UnderAttack {
+			if underAttack.Host == incident.Host {
+				add(underAttack.Since.Time, HostDiverted, incident.Diversion.Subnet, underAttack.Reason, 0)
+			}
+		}
+	}
+
+	sort.SliceStable(incident.Timeline, func(i, j int) bool {
+		a, b := incident.Timeline[i], incident.Timeline[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		return timelineOrder[a.Type] < timelineOrder[b.Type]
+	})
+}
+
+// computeMetrics derives the time to mitigate and the time to withdraw from the announcements of the incident
+func (incident *Incident) computeMetrics() {
+	if len(incident.Announcements) == 0 {
+		return
+	}
+
+	firstStart := incident.Announcements[0].Start.Time
+	var lastEnd time.Time
+	allEnded := true
+
+	for _, announcement := range incident.Announcements {
+		if announcement.Start.Before(firstStart) {
+			firstStart = announcement.Start.Time
+		}
+
+		if announcement.Active() {
+			allEnded = false
+		} else if announcement.End.After(lastEnd) {
+			lastEnd = announcement.End.Time
+		}
+	}
+
+	incident.TimeToMitigate = firstStart.Sub(incident.Start)
+	incident.Mitigated = true
+
+	if allEnded && !incident.End.IsZero() {
+		incident.TimeToWithdraw = lastEnd.Sub(incident.End)
+		incident.Withdrawn = true
+	}
+}
+
+// networkContains reports whether a network such as "203.0.113.0/24", or a single address, contains the IP
+func networkContains(network string, ip net.IP) bool {
+	if _, prefix, err := net.ParseCIDR(network); err == nil {
+		return prefix.Contains(ip)
+	}
+
+	if address := net.ParseIP(network); address != nil {
+		return address.Equal(ip)
+	}
+
+	return false
+}
@@ -0,0 +1,69 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/path-network/go-path"
+)
+
+// TestCorrelate ensures that overlapping attacks are merged and lined up with their announcements and diversion, and
+// that attacks without a start are left out
+func TestCorrelate(t *testing.T) {
+	at := func(hour, minute int) path.Timestamp {
+		return path.NewTimestamp(time.Date(2020, 1, 1, hour, minute, 0, 0, time.UTC))
+	}
+
+	attacks := path.AttackHistory{AttackHistory: []path.AttackDetails{
+		{Host: "203.0.113.5", Reason: "UDP flood", Start: at(10, 0), End: at(11, 0),
+			PeakBPS: path.BPSPeak{Value: 9000, Timestamp: at(10, 20)}},
+		{Host: "203.0.113.5", Reason: "SYN flood", Start: at(10, 30), End: at(11, 30)},
+		{Host: "203.0.113.5", Reason: "UDP flood", Start: at(14, 0)},
+		{Host: "198.51.100.1", Reason: "UDP flood", Start: at(12, 0), End: at(12, 10)},
+		{Host: "203.0.113.5", Reason: "UDP flood", End: at(9, 0)},
+	}}
+
+	announcements := path.AnnouncementHistory{AnnouncementHistory: []path.AnnouncementDetails{
+		{Net: "203.0.113.0/24", Start: at(10, 2), End: at(11, 45)},
+		{Net: "203.0.113.0/24", Start: at(14, 1)},
+	}}
+
+	diversions := path.Diversions{Diversions: []path.Diversion{
+		{Subnet: "203.0.113.0/24", UnderAttack: []path.UnderAttack{{Host: "203.0.113.5", Since: at(14, 1)}}},
+	}}
+
+	incidents := Correlate(attacks, announcements, diversions)
+	if len(incidents) != 3 {
+		t.Fatalf("Expected 3 incidents, got %+v\n", incidents)
+	}
+
+	first := incidents[0]
+	if len(first.Attacks) != 2 || !first.End.Equal(at(11, 30).Time) || first.Diversion != nil {
+		t.Errorf("Unexpected first incident: %+v\n", first)
+	}
+
+	if !first.Mitigated || first.TimeToMitigate != 2*time.Minute || !first.Withdrawn || first.TimeToWithdraw != 15*time.Minute {
+		t.Errorf("Unexpected metrics for first incident: %+v\n", first)
+	}
+
+	var types []TimelineEventType
+	for _, event := range first.Timeline {
+		types = append(types, event.Type)
+	}
+
+	expected := []TimelineEventType{
+		AttackStarted, AnnouncementStarted, PeakBPSReached, AttackStarted, AttackEnded, AttackEnded, AnnouncementEnded,
+	}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("Expected %v, got %v\n", expected, types)
+	}
+
+	if unmitigated := incidents[1]; unmitigated.Host != "198.51.100.1" || unmitigated.Mitigated {
+		t.Errorf("Unexpected second incident: %+v\n", unmitigated)
+	}
+
+	if ongoing := incidents[2]; ongoing.Diversion == nil || ongoing.Withdrawn || ongoing.TimeToMitigate != time.Minute {
+		t.Errorf("Unexpected ongoing incident: %+v\n", ongoing)
+	}
+}
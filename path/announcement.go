@@ -5,13 +5,82 @@ import "time"
 // AnnouncementDetails provides the details for a particular announcement
 type AnnouncementDetails struct {
 	// The network that the BGP announcement was made for
-	Net    string
-	Reason string
-	Start  time.Time
-	End    time.Time
+	Net    string       `json:"net"`
+	Reason AttackReason `json:"reason"`
+	Start  Timestamp    `json:"start"`
+	// End is the zero time while the announcement is still active
+	End Timestamp `json:"end"`
 }
 
 // AnnouncementHistory possesses the history of BGP announcements
 type AnnouncementHistory struct {
 	AnnouncementHistory []AnnouncementDetails `json:"announcement_history"`
 }
+
+// AnnouncementFilter selects announcements from an AnnouncementHistory. Zero-valued fields are not used to filter
+type AnnouncementFilter struct {
+	// Since and Until select announcements which were active at any point in the range
+	Since time.Time
+	Until time.Time
+	// Prefix selects announcements whose network overlaps this address or prefix
+	Prefix string
+	// ActiveOnly selects announcements which have not ended yet
+	ActiveOnly bool
+}
+
+// Active reports whether the announcement has not ended yet
+func (details AnnouncementDetails) Active() bool {
+	return details.End.IsZero()
+}
+
+// ActiveAt reports whether the announcement was active at the given time
+func (details AnnouncementDetails) ActiveAt(t time.Time) bool {
+	return !t.Before(details.Start.Time) && (details.Active() || t.Before(details.End.Time))
+}
+
+// Active returns the announcements which have not ended yet
+func (history AnnouncementHistory) Active() []AnnouncementDetails {
+	var active []AnnouncementDetails
+	for _, details := range history.AnnouncementHistory {
+		if details.Active() {
+			active = append(active, details)
+		}
+	}
+
+	return active
+}
+
+// Filter returns the announcements matching the filter, in their original order. An error is returned if the prefix of
+// the filter is not a valid address or prefix
+func (history AnnouncementHistory) Filter(filter AnnouncementFilter) ([]AnnouncementDetails, error) {
+	var matches []AnnouncementDetails
+
+	for _, details := range history.AnnouncementHistory {
+		if filter.ActiveOnly && !details.Active() {
+			continue
+		}
+
+		if !filter.Until.IsZero() && !details.Start.Before(filter.Until) {
+			continue
+		}
+
+		if !filter.Since.IsZero() && !details.Active() && details.End.Before(filter.Since) {
+			continue
+		}
+
+		if filter.Prefix != "" {
+			overlap, err := prefixesOverlap(filter.Prefix, details.Net)
+			if err != nil {
+				return nil, err
+			}
+
+			if !overlap {
+				continue
+			}
+		}
+
+		matches = append(matches, details)
+	}
+
+	return matches, nil
+}
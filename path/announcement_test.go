@@ -0,0 +1,54 @@
+package path
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestGetAnnouncementHistory ensures that announcements are fetched from the announcement history endpoint and filtered
+func TestGetAnnouncementHistory(t *testing.T) {
+	client, _, closeAPI := newTestClient(t, map[string]mockResponse{
+		"GET /announcement_history": {http.StatusOK, `{"announcement_history":[
+			{"net":"203.0.113.0/24","reason":"UDP flood","start":"2020-01-01T10:00:00Z","end":"2020-01-01T11:00:00Z"},
+			{"net":"198.51.100.0/24","reason":"SYN flood","start":"2020-01-02T10:00:00Z","end":null},
+			{"net":"203.0.113.0/24","reason":"Manual","start":"2020-01-03T10:00:00Z","end":null}
+		]}`},
+	})
+	defer closeAPI()
+
+	history, err := client.GetAnnouncementHistory()
+	if err != nil {
+		t.Fatalf("Error fetching announcement history: %s\n", err.Error())
+	}
+
+	if len(history.AnnouncementHistory) != 3 || history.AnnouncementHistory[0].Net != "203.0.113.0/24" {
+		t.Fatalf("Unexpected announcement history: %+v\n", history)
+	}
+
+	if active := history.Active(); len(active) != 2 {
+		t.Errorf("Expected 2 active announcements, got %+v\n", active)
+	}
+
+	cases := []struct {
+		name     string
+		filter   AnnouncementFilter
+		expected int
+	}{
+		{"prefix", AnnouncementFilter{Prefix: "203.0.113.5"}, 2},
+		{"since", AnnouncementFilter{Since: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}, 2},
+		{"until", AnnouncementFilter{Until: time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC)}, 1},
+		{"active prefix", AnnouncementFilter{Prefix: "203.0.113.0/16", ActiveOnly: true}, 1},
+	}
+
+	for _, c := range cases {
+		got, err := history.Filter(c.filter)
+		if err != nil {
+			t.Fatalf("%s: error filtering announcements: %s\n", c.name, err.Error())
+		}
+
+		if len(got) != c.expected {
+			t.Errorf("%s: expected %d announcements, got %+v\n", c.name, c.expected, got)
+		}
+	}
+}
@@ -1,7 +1,5 @@
 package path
 
-import "time"
-
 // AttackHistory stores the history of all attacks detected for hosts under your account
 type AttackHistory struct {
 	AttackHistory []AttackDetails `json:"attack_history"`
@@ -9,17 +7,37 @@ type AttackHistory struct {
 
 // AttackDetails gives us the details of an attack that occurred
 type AttackDetails struct {
-	Host    string     `json:"host"`
-	Reason  string     `json:"reason"`
-	Start   time.Time  `json:"start"`
-	End     time.Time  `json:"end"`
-	PeakBPS AttackPeak `json:"peak_bps"`
-	PeakPPS AttackPeak `json:"peak_pps"`
+	Host    string       `json:"host"`
+	Reason  AttackReason `json:"reason"`
+	Start   Timestamp    `json:"start"`
+	End     Timestamp    `json:"end"`
+	PeakBPS BPSPeak      `json:"peak_bps"`
+	PeakPPS PPSPeak      `json:"peak_pps"`
+}
+
+// BPSPeak represents the highest bits per second reached during a particular attack, and when it was reached
+type BPSPeak struct {
+	Value     BPS       `json:"value"`
+	Timestamp Timestamp `json:"timestamp"`
+}
+
+// PPSPeak represents the highest packets per second reached during a particular attack, and when it was reached
+type PPSPeak struct {
+	Value     PPS       `json:"value"`
+	Timestamp Timestamp `json:"timestamp"`
 }
 
 // AttackPeak represents a peak value that happened during a particular attack, such as the bits per second or packets
 // per second
+//
+// Deprecated: AttackDetails holds its peaks as a BPSPeak and a PPSPeak, which tell bit rates and packet rates apart and
+// do not overflow on 32-bit platforms. AttackPeak will be removed in the next release
 type AttackPeak struct {
 	Value     int       `json:"value"`
-	Timestamp time.Time `json:"timestamp"`
+	Timestamp Timestamp `json:"timestamp"`
+}
+
+// PeakAtLeast reports whether the attack peaked at or above both of the given rates. Pass zero for a rate to ignore it
+func (details AttackDetails) PeakAtLeast(bps BPS, pps PPS) bool {
+	return details.PeakBPS.Value >= bps && details.PeakPPS.Value >= pps
 }
@@ -0,0 +1,268 @@
+package path
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// AttackHistoryQuery selects attacks from the attack history. Zero-valued fields are not used to filter.
+//
+// Since, Until and Host are sent to the API as query parameters so that it can narrow down the history it returns.
+// They are also applied by the client, so the results are the same whether or not the API honours them. Limit and
+// Offset are applied by the client too. Offset is never sent, as the client could not tell whether the API skipped the
+// attacks already, but the API is asked for at most Offset+Limit attacks so that it does not send more than the page
+// needs. That limit is left out when Prefix, MinPeakBPS or MinPeakPPS is set: as only the client can apply those, the
+// API would count attacks which are then filtered out
+type AttackHistoryQuery struct {
+	// Since and Until select attacks which were ongoing at any point in the range
+	Since time.Time
+	Until time.Time
+	// Host selects attacks against this host
+	Host string
+	// Prefix selects attacks against hosts within this prefix
+	Prefix string
+	// MinPeakBPS and MinPeakPPS select attacks whose peak reached at least this value
+	MinPeakBPS BPS
+	MinPeakPPS PPS
+	// Offset skips this many matching attacks, and Limit stops after this many matching attacks have been returned
+	Limit  int
+	Offset int
+}
+
+// limitedByAPI reports whether the API can be asked to limit the attacks it returns, i.e. whether every field filtering
+// the attacks is one the API applies too
+func (query AttackHistoryQuery) limitedByAPI() bool {
+	return query.Prefix == "" && query.MinPeakBPS == 0 && query.MinPeakPPS == 0
+}
+
+// QueryAttackHistory fetches the attacks matching the query
+func (client *Client) QueryAttackHistory(query AttackHistoryQuery) (AttackHistory, error) {
+	iterator := client.IterateAttackHistory(query)
+	defer iterator.Close()
+
+	var history AttackHistory
+	for iterator.Next() {
+		history.AttackHistory = append(history.AttackHistory, iterator.Details())
+	}
+
+	return history, iterator.Err()
+}
+
+// AttackHistoryIterator streams the attacks matching a query. The response is decoded one attack at a time, so that
+// large histories are never held in memory at once:
+//
+//	iterator := client.IterateAttackHistory(query)
+//	defer iterator.Close()
+//
+//	for iterator.Next() {
+//		details := iterator.Details()
+//		. . .
+//	}
+//
+//	if err := iterator.Err(); err != nil {
+//		. . .
+//	}
+type AttackHistoryIterator struct {
+	client  *Client
+	query   AttackHistoryQuery
+	prefix  *net.IPNet
+	body    io.ReadCloser
+	decoder *json.Decoder
+	details AttackDetails
+	skipped int
+	matched int
+	done    bool
+	err     error
+}
+
+// IterateAttackHistory returns an iterator over the attacks matching the query. No request is made until the first
+// call to Next
+func (client *Client) IterateAttackHistory(query AttackHistoryQuery) *AttackHistoryIterator {
+	iterator := &AttackHistoryIterator{client: client, query: query}
+
+	if query.Prefix != "" {
+		iterator.prefix, iterator.err = parsePrefix(query.Prefix)
+		iterator.done = iterator.err != nil
+	}
+
+	return iterator
+}
+
+// Next advances the iterator to the next matching attack. It returns false once the history is exhausted or an error
+// occurred, which can be told apart by calling Err
+func (iterator *AttackHistoryIterator) Next() bool {
+	if iterator.done {
+		return false
+	}
+
+	if iterator.decoder == nil {
+		if err := iterator.open(); err != nil {
+			return iterator.fail(err)
+		}
+	}
+
+	for {
+		if iterator.query.Limit > 0 && iterator.matched >= iterator.query.Limit {
+			iterator.Close()
+			return false
+		}
+
+		if !iterator.decoder.More() {
+			iterator.Close()
+			return false
+		}
+
+		var details AttackDetails
+		if err := iterator.decoder.Decode(&details); err != nil {
+			return iterator.fail(err)
+		}
+
+		if !iterator.query.matches(details, iterator.prefix) {
+			continue
+		}
+
+		if iterator.skipped < iterator.query.Offset {
+			iterator.skipped++
+			continue
+		}
+
+		iterator.matched++
+		iterator.details = details
+
+		return true
+	}
+}
+
+// Details returns the attack the iterator is positioned at
+func (iterator *AttackHistoryIterator) Details() AttackDetails {
+	return iterator.details
+}
+
+// Err returns the error which stopped the iteration, if any
+func (iterator *AttackHistoryIterator) Err() error {
+	return iterator.err
+}
+
+// Close releases the response being streamed. It is safe to call Close more than once
+func (iterator *AttackHistoryIterator) Close() error {
+	iterator.done = true
+
+	if iterator.body == nil {
+		return nil
+	}
+
+	err := iterator.body.Close()
+	iterator.body = nil
+
+	return err
+}
+
+// fail stops the iteration with the given error
+func (iterator *AttackHistoryIterator) fail(err error) bool {
+	iterator.err = err
+	iterator.Close()
+
+	return false
+}
+
+// open requests the attack history and positions the decoder at the start of the attack_history array
+func (iterator *AttackHistoryIterator) open() error {
+	params := url.Values{}
+	if !iterator.query.Since.IsZero() {
+		params.Set("since", iterator.query.Since.UTC().Format(time.RFC3339))
+	}
+	if !iterator.query.Until.IsZero() {
+		params.Set("until", iterator.query.Until.UTC().Format(time.RFC3339))
+	}
+	if iterator.query.Host != "" {
+		params.Set("host", iterator.query.Host)
+	}
+	if iterator.query.Limit > 0 && iterator.query.limitedByAPI() {
+		params.Set("limit", strconv.Itoa(iterator.query.Offset+iterator.query.Limit))
+	}
+
+	endpoint := iterator.client.baseURL + "/attack_history"
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	iterator.body, err = iterator.client.streamRequest(req)
+	if err != nil {
+		return err
+	}
+
+	iterator.decoder = json.NewDecoder(iterator.body)
+
+	if err := expectDelim(iterator.decoder, '{'); err != nil {
+		return err
+	}
+
+	// Skip over any other members of the response until the attack history is reached
+	for iterator.decoder.More() {
+		key, err := iterator.decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		if key == "attack_history" {
+			return expectDelim(iterator.decoder, '[')
+		}
+
+		var skipped json.RawMessage
+		if err := iterator.decoder.Decode(&skipped); err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("response does not contain an attack history")
+}
+
+// expectDelim reads the next token and checks that it is the given delimiter
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	if token != delim {
+		return fmt.Errorf("unexpected token %v in response, expected %v", token, delim)
+	}
+
+	return nil
+}
+
+// matches reports whether an attack satisfies every field of the query except Limit and Offset
+func (query AttackHistoryQuery) matches(details AttackDetails, prefix *net.IPNet) bool {
+	if !query.Until.IsZero() && !details.Start.Before(query.Until) {
+		return false
+	}
+
+	if !query.Since.IsZero() && !details.End.IsZero() && details.End.Before(query.Since) {
+		return false
+	}
+
+	if query.Host != "" && details.Host != query.Host {
+		return false
+	}
+
+	if prefix != nil {
+		if ip := net.ParseIP(details.Host); ip == nil || !prefix.Contains(ip) {
+			return false
+		}
+	}
+
+	return details.PeakAtLeast(query.MinPeakBPS, query.MinPeakPPS)
+}
@@ -0,0 +1,93 @@
+package path
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testAttackHistory = `{"count":4,"attack_history":[
+	{"host":"203.0.113.5","reason":"UDP flood","start":"2020-01-01T10:00:00Z","end":"2020-01-01T11:00:00Z",
+		"peak_bps":{"value":1000},"peak_pps":{"value":10}},
+	{"host":"203.0.113.6","reason":"SYN flood","start":"2020-01-02T10:00:00Z","end":"2020-01-02T11:00:00Z",
+		"peak_bps":{"value":5000},"peak_pps":{"value":50}},
+	{"host":"198.51.100.7","reason":"DNS amplification","start":"2020-01-03T10:00:00Z","end":"2020-01-03T11:00:00Z",
+		"peak_bps":{"value":9000},"peak_pps":{"value":90}},
+	{"host":"203.0.113.5","reason":"UDP flood","start":"2020-01-04T10:00:00Z","end":null,
+		"peak_bps":{"value":7000},"peak_pps":{"value":70}}
+]}`
+
+// TestQueryAttackHistory ensures that query options are sent to the API and applied to the streamed history
+func TestQueryAttackHistory(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"GET /attack_history": {http.StatusOK, testAttackHistory},
+	})
+	defer closeAPI()
+
+	cases := []struct {
+		name          string
+		query         AttackHistoryQuery
+		expectedQuery string
+		expected      []string
+	}{
+		{"all", AttackHistoryQuery{}, "", []string{"2020-01-01", "2020-01-02", "2020-01-03", "2020-01-04"}},
+		{"since", AttackHistoryQuery{Since: time.Date(2020, 1, 2, 10, 30, 0, 0, time.UTC)},
+			"since=2020-01-02T10%3A30%3A00Z", []string{"2020-01-02", "2020-01-03", "2020-01-04"}},
+		{"host", AttackHistoryQuery{Host: "203.0.113.5"}, "host=203.0.113.5", []string{"2020-01-01", "2020-01-04"}},
+		{"prefix and peak", AttackHistoryQuery{Prefix: "203.0.113.0/24", MinPeakBPS: 2000}, "",
+			[]string{"2020-01-02", "2020-01-04"}},
+		{"paging after filtering by prefix", AttackHistoryQuery{Prefix: "203.0.113.0/24", Offset: 1, Limit: 1}, "",
+			[]string{"2020-01-02"}},
+		{"paging ignored by the API", AttackHistoryQuery{Offset: 1, Limit: 2}, "limit=3",
+			[]string{"2020-01-02", "2020-01-03"}},
+	}
+
+	for _, c := range cases {
+		api.queries = nil
+
+		history, err := client.QueryAttackHistory(c.query)
+		if err != nil {
+			t.Fatalf("%s: error querying attack history: %s\n", c.name, err.Error())
+		}
+
+		var got []string
+		for _, details := range history.AttackHistory {
+			got = append(got, details.Start.Format("2006-01-02"))
+		}
+
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("%s: expected %v, got %v\n", c.name, c.expected, got)
+		}
+
+		if len(api.queries) != 1 || api.queries[0] != c.expectedQuery {
+			t.Errorf("%s: expected query %q, got %v\n", c.name, c.expectedQuery, api.queries)
+		}
+	}
+}
+
+// TestQueryAttackHistoryPaging ensures that the API is asked for the attacks up to the end of the page, and that the
+// client skips the offset itself
+func TestQueryAttackHistoryPaging(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"GET /attack_history": {http.StatusOK, `{"attack_history":[
+			{"host":"203.0.113.5","reason":"UDP flood","start":"2020-01-01T10:00:00Z","end":"2020-01-01T11:00:00Z"},
+			{"host":"203.0.113.6","reason":"SYN flood","start":"2020-01-02T10:00:00Z","end":"2020-01-02T11:00:00Z"},
+			{"host":"198.51.100.7","reason":"UDP flood","start":"2020-01-03T10:00:00Z","end":"2020-01-03T11:00:00Z"}
+		]}`},
+	})
+	defer closeAPI()
+
+	history, err := client.QueryAttackHistory(AttackHistoryQuery{Offset: 1, Limit: 2})
+	if err != nil {
+		t.Fatalf("Error querying attack history: %s\n", err.Error())
+	}
+
+	if len(history.AttackHistory) != 2 || history.AttackHistory[0].Host != "203.0.113.6" {
+		t.Errorf("Expected the second and third attacks, got %+v\n", history.AttackHistory)
+	}
+
+	if expected := "limit=3"; len(api.queries) != 1 || api.queries[0] != expected {
+		t.Errorf("Expected %+v, got %+v\n", expected, api.queries)
+	}
+}
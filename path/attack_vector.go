@@ -0,0 +1,116 @@
+package path
+
+import (
+	"regexp"
+	"strings"
+)
+
+// AttackReason is the free-text reason the API gives for a diversion, attack or announcement, such as "UDP flood" or
+// "DNS amplification". The raw value is preserved, while Vectors classifies it into known attack vectors
+type AttackReason string
+
+// AttackVector is a classification of an attack
+type AttackVector string
+
+// Attack vectors recognized in reasons
+const (
+	VectorUnknown                  AttackVector = "unknown"
+	VectorUDPFlood                 AttackVector = "udp_flood"
+	VectorTCPFlood                 AttackVector = "tcp_flood"
+	VectorSYNFlood                 AttackVector = "syn_flood"
+	VectorACKFlood                 AttackVector = "ack_flood"
+	VectorRSTFlood                 AttackVector = "rst_flood"
+	VectorICMPFlood                AttackVector = "icmp_flood"
+	VectorGREFlood                 AttackVector = "gre_flood"
+	VectorFragmentation            AttackVector = "fragmentation"
+	VectorDNSAmplification         AttackVector = "dns_amplification"
+	VectorNTPAmplification         AttackVector = "ntp_amplification"
+	VectorSSDPAmplification        AttackVector = "ssdp_amplification"
+	VectorMemcachedAmplification   AttackVector = "memcached_amplification"
+	VectorCLDAPAmplification       AttackVector = "cldap_amplification"
+	VectorCharGenAmplification     AttackVector = "chargen_amplification"
+	VectorSNMPAmplification        AttackVector = "snmp_amplification"
+	VectorWSDiscoveryAmplification AttackVector = "ws_discovery_amplification"
+)
+
+// attackVectorPatterns match the normalized reason, in which every run of characters other than letters and digits is
+// replaced by a single space
+var attackVectorPatterns = []struct {
+	vector  AttackVector
+	pattern *regexp.Regexp
+}{
+	{VectorSYNFlood, regexp.MustCompile(`\bsyn\b`)},
+	{VectorACKFlood, regexp.MustCompile(`\back\b`)},
+	{VectorRSTFlood, regexp.MustCompile(`\brst\b`)},
+	{VectorICMPFlood, regexp.MustCompile(`\bicmp\b`)},
+	{VectorGREFlood, regexp.MustCompile(`\bgre\b`)},
+	{VectorFragmentation, regexp.MustCompile(`\bfrag(ment|ments|mented|mentation)?\b`)},
+	{VectorDNSAmplification, regexp.MustCompile(`\bdns\b.*\b(amp|amplification|reflection|reflected)\b`)},
+	{VectorNTPAmplification, regexp.MustCompile(`\bntp\b`)},
+	{VectorSSDPAmplification, regexp.MustCompile(`\bssdp\b`)},
+	{VectorMemcachedAmplification, regexp.MustCompile(`\bmemcached?\b`)},
+	{VectorCLDAPAmplification, regexp.MustCompile(`\bc?ldap\b`)},
+	{VectorCharGenAmplification, regexp.MustCompile(`\bchargen\b`)},
+	{VectorSNMPAmplification, regexp.MustCompile(`\bsnmp\b`)},
+	{VectorWSDiscoveryAmplification, regexp.MustCompile(`\bws ?discovery\b`)},
+}
+
+// Generic floods are only reported if no more specific vector of the same protocol was recognized
+var (
+	tcpPattern = regexp.MustCompile(`\btcp\b`)
+	udpPattern = regexp.MustCompile(`\budp\b`)
+)
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Vectors returns every attack vector recognized in the reason, in a fixed order. A reason which mentions no known
+// vector yields VectorUnknown
+func (reason AttackReason) Vectors() []AttackVector {
+	normalized := nonAlphanumeric.ReplaceAllString(strings.ToLower(string(reason)), " ")
+
+	var vectors []AttackVector
+	tcpSpecific, udpSpecific := false, false
+
+	for _, candidate := range attackVectorPatterns {
+		if !candidate.pattern.MatchString(normalized) {
+			continue
+		}
+
+		vectors = append(vectors, candidate.vector)
+		switch {
+		case candidate.vector == VectorSYNFlood || candidate.vector == VectorACKFlood || candidate.vector == VectorRSTFlood:
+			tcpSpecific = true
+		case candidate.vector.Amplification():
+			udpSpecific = true
+		}
+	}
+
+	if !tcpSpecific && tcpPattern.MatchString(normalized) {
+		vectors = append(vectors, VectorTCPFlood)
+	}
+
+	if !udpSpecific && udpPattern.MatchString(normalized) {
+		vectors = append(vectors, VectorUDPFlood)
+	}
+
+	if len(vectors) == 0 {
+		return []AttackVector{VectorUnknown}
+	}
+
+	return vectors
+}
+
+// Vector returns the first attack vector recognized in the reason, or VectorUnknown
+func (reason AttackReason) Vector() AttackVector {
+	return reason.Vectors()[0]
+}
+
+// String returns the raw reason
+func (reason AttackReason) String() string {
+	return string(reason)
+}
+
+// Amplification reports whether the vector is a reflection/amplification attack
+func (vector AttackVector) Amplification() bool {
+	return strings.HasSuffix(string(vector), "_amplification")
+}
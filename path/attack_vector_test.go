@@ -0,0 +1,27 @@
+package path
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestAttackReasonVectors ensures that free-text reasons are classified into attack vectors
+func TestAttackReasonVectors(t *testing.T) {
+	cases := []struct {
+		reason   AttackReason
+		expected []AttackVector
+	}{
+		{"UDP flood", []AttackVector{VectorUDPFlood}},
+		{"TCP SYN flood", []AttackVector{VectorSYNFlood}},
+		{"tcp_flood", []AttackVector{VectorTCPFlood}},
+		{"DNS amplification (UDP/53)", []AttackVector{VectorDNSAmplification}},
+		{"memcached reflection, UDP fragments", []AttackVector{VectorFragmentation, VectorMemcachedAmplification}},
+		{"Manual", []AttackVector{VectorUnknown}},
+	}
+
+	for _, c := range cases {
+		if got := c.reason.Vectors(); !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("%q: expected %v, got %v\n", c.reason, c.expected, got)
+		}
+	}
+}
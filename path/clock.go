@@ -0,0 +1,26 @@
+package path
+
+import "time"
+
+// Clock provides the current time to the helpers in this package that act on expiries and schedules. It can be
+// replaced in order to test time-dependent behaviour deterministically
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is a Clock backed by the system's wall clock
+type SystemClock struct{}
+
+// Now returns the current local time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// clockOrSystem returns the given clock, falling back to the system clock if none was provided
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock{}
+	}
+
+	return clock
+}
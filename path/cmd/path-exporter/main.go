@@ -0,0 +1,59 @@
+// Command path-exporter serves the protection state of a Path account as Prometheus metrics. The credentials are those
+// of the selected profile, as resolved by path.LoadCredentialProfile. The exporter authenticates again whenever its
+// token expires or is rejected by the API.
+//
+// Usage:
+//
+//	path-exporter [-listen :9788] [-interval 1m] [-base-url https://api.path.net] [-profile name]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/path-network/go-path"
+	"github.com/path-network/go-path/metrics"
+)
+
+func main() {
+	listen := flag.String("listen", ":9788", "address to serve metrics on")
+	interval := flag.Duration("interval", time.Minute, "time between collections")
+	baseURL := flag.String("base-url", "", "base URL of the API, if not the one of the profile")
+	profile := flag.String("profile", "", "credential profile, if not the default")
+	flag.Parse()
+
+	if *interval <= 0 {
+		log.Fatalf("The interval must be positive, got %s\n", *interval)
+	}
+
+	var options []path.ClientOption
+	if *baseURL != "" {
+		options = append(options, path.WithBaseURL(*baseURL))
+	}
+
+	authenticate := func() (*path.Client, error) {
+		client, err := path.NewClientFromProfile(*profile, options...)
+		return &client, err
+	}
+
+	client, err := authenticate()
+	if err != nil {
+		log.Fatalf("Error authenticating: %s\n", err.Error())
+	}
+
+	exporter := &metrics.Exporter{
+		Client:       client,
+		Authenticate: authenticate,
+		Interval:     *interval,
+		OnError: func(err error) {
+			log.Printf("Error collecting metrics: %s\n", err.Error())
+		},
+	}
+	go exporter.Run(context.Background())
+
+	http.Handle("/metrics", exporter)
+	log.Fatal(http.ListenAndServe(*listen, nil))
+}
@@ -0,0 +1,78 @@
+// Command path-filtergen generates typed Go settings for the application filters of Path, for use with go generate.
+// The schema of the filters is read from a FiltersOptions JSON document, or fetched from the API if no input is given,
+// using the credentials of the selected profile as resolved by path.LoadCredentialProfile.
+//
+// Usage:
+//
+//	path-filtergen [-input filters.json] [-output filters.go] [-package name] [-profile name]
+//
+// The package defaults to $GOPACKAGE, which go generate sets to the package of the file being processed.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/path-network/go-path"
+	"github.com/path-network/go-path/filtergen"
+)
+
+func main() {
+	input := flag.String("input", "",
+		"FiltersOptions JSON document to read, or - for stdin. If empty, the schema is fetched from the API")
+	output := flag.String("output", "", "file to write, instead of stdout")
+	packageName := flag.String("package", os.Getenv("GOPACKAGE"), "package of the generated file")
+	profile := flag.String("profile", "", "credential profile used to fetch the schema, if not the default")
+	flag.Parse()
+
+	options, err := readOptions(*input, *profile)
+	if err != nil {
+		log.Fatalf("Error reading filter options: %s\n", err.Error())
+	}
+
+	source, err := filtergen.Generate(options, filtergen.Config{Package: *packageName})
+	if err != nil {
+		log.Fatalf("Error generating code: %s\n", err.Error())
+	}
+
+	if *output == "" {
+		os.Stdout.Write(source)
+		return
+	}
+
+	if err := ioutil.WriteFile(*output, source, 0644); err != nil {
+		log.Fatalf("Error writing %s: %s\n", *output, err.Error())
+	}
+}
+
+// readOptions reads the schema of the filters from a file, stdin or the API
+func readOptions(input, profile string) (path.FiltersOptions, error) {
+	var options path.FiltersOptions
+
+	switch input {
+	case "":
+		client, err := path.NewClientFromProfile(profile)
+		if err != nil {
+			return options, err
+		}
+
+		return client.GetFilterOptions()
+	case "-":
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return options, err
+		}
+
+		return options, json.Unmarshal(data, &options)
+	default:
+		data, err := ioutil.ReadFile(input)
+		if err != nil {
+			return options, err
+		}
+
+		return options, json.Unmarshal(data, &options)
+	}
+}
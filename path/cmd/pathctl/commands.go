@@ -0,0 +1,664 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/path-network/go-path"
+)
+
+// runFunc runs a command with its positional arguments and returns the result to print
+type runFunc func(env *environment, args []string) (interface{}, error)
+
+// command is a single action on a resource, such as "rules list"
+type command struct {
+	resource string
+	action   string
+	// args names the positional arguments, separated by spaces
+	args    string
+	summary string
+	// columns are the fields shown by the table output, as dotted paths into the JSON encoding of each result
+	columns []string
+	// setup registers the flags of the command and returns the function running it
+	setup func(flags *flag.FlagSet) runFunc
+}
+
+// Columns shown for each kind of resource
+var (
+	ruleColumns = []string{"id", "destination", "source", "protocol", "dst_port", "src_port", "whitelist", "priority",
+		"rate_limiter_id", "comment"}
+	rateLimiterColumns  = []string{"id", "packets_per_second", "comment"}
+	diversionColumns    = []string{"subnet", "manual", "under_attack"}
+	filterColumns       = []string{"id", "name"}
+	filterOptionColumns = []string{"name", "label", "description"}
+	attackColumns       = []string{"host", "reason", "start", "end", "peak_bps.value", "peak_pps.value"}
+	announcementColumns = []string{"net", "reason", "start", "end"}
+)
+
+// dryRun describes a change which would have been made without -dry-run
+type dryRun struct {
+	Action string      `json:"action"`
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Body   interface{} `json:"body,omitempty"`
+}
+
+func (dryRun) tableColumns() []string {
+	return []string{"action", "method", "path", "body"}
+}
+
+// deleted reports the removal of a resource
+type deleted struct {
+	Status   string `json:"status"`
+	Resource string `json:"resource"`
+	ID       string `json:"id"`
+}
+
+func (deleted) tableColumns() []string {
+	return []string{"status", "resource", "id"}
+}
+
+// remove deletes a resource unless this is a dry run
+func remove(env *environment, resource, id, endpoint string, deleteFunc func(*path.Client) error) (interface{}, error) {
+	if env.dryRun {
+		return dryRun{Action: "delete " + resource, Method: http.MethodDelete, Path: endpoint}, nil
+	}
+
+	client, err := env.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := deleteFunc(client); err != nil {
+		return nil, err
+	}
+
+	return deleted{Status: "deleted", Resource: resource, ID: id}, nil
+}
+
+// withClient adapts a function which only needs the client and the arguments into a runFunc
+func withClient(run func(client *path.Client, args []string) (interface{}, error)) runFunc {
+	return func(env *environment, args []string) (interface{}, error) {
+		client, err := env.getClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return run(client, args)
+	}
+}
+
+// parsePrefix splits a prefix such as "203.0.113.0/24" into its network address and length
+func parsePrefix(prefix string) (string, int, error) {
+	_, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return "", 0, usagef("invalid prefix %q", prefix)
+	}
+
+	length, _ := network.Mask.Size()
+
+	return network.IP.String(), length, nil
+}
+
+// labelFlag collects repeated -label key=value flags
+type labelFlag path.Labels
+
+func (labels labelFlag) String() string {
+	return path.Labels(labels).String()
+}
+
+func (labels labelFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return errors.New("labels must be given as key=value")
+	}
+
+	labels[parts[0]] = parts[1]
+
+	return nil
+}
+
+// settingsFlag collects repeated -set field=value flags. Values are decoded as JSON where possible, so that numbers,
+// booleans and lists keep their type, and are taken as strings otherwise
+type settingsFlag path.FilterSettings
+
+func (settings settingsFlag) String() string {
+	data, _ := json.Marshal(path.FilterSettings(settings))
+	return string(data)
+}
+
+func (settings settingsFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return errors.New("settings must be given as field=value")
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(parts[1]), &decoded); err != nil {
+		decoded = parts[1]
+	}
+
+	settings[parts[0]] = decoded
+
+	return nil
+}
+
+// timeFlag holds a point in time, given either as a timestamp or as a duration before now such as "24h"
+type timeFlag struct {
+	time.Time
+}
+
+func (flagTime *timeFlag) String() string {
+	if flagTime.IsZero() {
+		return ""
+	}
+
+	return flagTime.Format(time.RFC3339)
+}
+
+func (flagTime *timeFlag) Set(value string) error {
+	if duration, err := time.ParseDuration(value); err == nil {
+		flagTime.Time = time.Now().Add(-duration)
+		return nil
+	}
+
+	timestamp, err := path.ParseTimestamp(value)
+	if err != nil {
+		return err
+	}
+
+	flagTime.Time = timestamp.Time
+
+	return nil
+}
+
+// rateLimiterFlag holds an optional rate limiter ID for a rule
+type rateLimiterFlag struct {
+	id *string
+}
+
+func (rateLimiter *rateLimiterFlag) String() string {
+	if rateLimiter.id == nil {
+		return ""
+	}
+
+	return *rateLimiter.id
+}
+
+func (rateLimiter *rateLimiterFlag) Set(value string) error {
+	rateLimiter.id = &value
+	return nil
+}
+
+// commands lists every command of pathctl. Resources are listed in the usage in alphabetical order, and actions in
+// the order they appear here
+var commands = []command{
+	{
+		resource: "auth", action: "status", summary: "Authenticate, or reuse a cached token, and show the token's scopes",
+		setup: func(flags *flag.FlagSet) runFunc {
+			return withClient(func(client *path.Client, args []string) (interface{}, error) {
+				token := client.Token()
+				return tokenStatus{TokenType: token.TokenType, Expiry: token.Expiry, Scope: token.Scope}, nil
+			})
+		},
+	},
+	{
+		resource: "auth", action: "logout", summary: "Revoke the token of the profile and remove it from the token cache",
+		setup: func(flags *flag.FlagSet) runFunc {
+			return func(env *environment, args []string) (interface{}, error) {
+				if env.dryRun {
+					return dryRun{Action: "revoke token", Method: http.MethodPost, Path: "/token/revoke"}, nil
+				}
+
+				// Without a cached token there is nothing to revoke, and authenticating only to revoke the new token
+				// would be wasted
+				options := append(env.clientOptions(), path.WithCachedTokenOnly())
+				client, err := path.NewClientFromProfile(env.profile, options...)
+				if errors.Is(err, path.ErrNoCachedToken) {
+					return deleted{Status: "not logged in", Resource: "token", ID: env.profile}, nil
+				}
+				if err != nil {
+					return nil, err
+				}
+
+				if err := client.Logout(); err != nil {
+					return nil, err
+				}
+
+				return deleted{Status: "revoked", Resource: "token", ID: env.profile}, nil
+			}
+		},
+	},
+	// Rules have no update action, as the API cannot change a rule: it has to be deleted and created again
+	{
+		resource: "rules", action: "list", summary: "List the firewall rules, optionally only those matching a selector",
+		columns: ruleColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			selector := flags.String("selector", "", "label selector, such as \"team=edge,env!=staging\"")
+
+			return withClient(func(client *path.Client, args []string) (interface{}, error) {
+				parsed, err := path.ParseSelector(*selector)
+				if err != nil {
+					return nil, usagef("invalid selector: %v", err)
+				}
+
+				rules, err := client.GetRules()
+				if err != nil {
+					return nil, err
+				}
+
+				return rules.Select(parsed), nil
+			})
+		},
+	},
+	{
+		resource: "rules", action: "get", args: "ID", summary: "Show a firewall rule", columns: ruleColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			return withClient(func(client *path.Client, args []string) (interface{}, error) {
+				return client.GetRule(args[0])
+			})
+		},
+	},
+	{
+		resource: "rules", action: "create", summary: "Create a firewall rule", columns: ruleColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			var rule path.Rule
+			flags.StringVar(&rule.Destination, "destination", "", "destination address or prefix (required)")
+			flags.StringVar(&rule.Source, "source", "", "source address or prefix")
+			flags.StringVar(&rule.Protocol, "protocol", "", "protocol, such as tcp or udp")
+			flags.IntVar(&rule.DstPort, "dst-port", 0, "destination port")
+			flags.IntVar(&rule.SrcPort, "src-port", 0, "source port")
+			flags.BoolVar(&rule.Whitelist, "whitelist", false, "allow the traffic instead of dropping it")
+			flags.BoolVar(&rule.Priority, "priority", false, "evaluate the rule before others")
+			flags.StringVar(&rule.Comment, "comment", "", "comment describing the rule")
+			rateLimiter := &rateLimiterFlag{}
+			flags.Var(rateLimiter, "rate-limiter", "ID of the rate limiter to apply to matching traffic")
+			labels := labelFlag{}
+			flags.Var(labels, "label", "label stored in the comment, as key=value (repeatable)")
+			ttl := flags.Duration("ttl", 0, "remove the rule once this long has elapsed, e.g. with a rule sweeper")
+
+			return func(env *environment, args []string) (interface{}, error) {
+				if rule.Destination == "" {
+					return nil, usagef("-destination is required")
+				}
+
+				rule.RateLimiterID = rateLimiter.id
+				for key, value := range labels {
+					rule.SetLabel(key, value)
+				}
+				if *ttl > 0 {
+					rule.SetExpiry(time.Now().Add(*ttl))
+				}
+
+				if env.dryRun {
+					return dryRun{Action: "create rule", Method: http.MethodPost, Path: "/rules", Body: rule}, nil
+				}
+
+				client, err := env.getClient()
+				if err != nil {
+					return nil, err
+				}
+
+				return client.CreateRule(rule)
+			}
+		},
+	},
+	{
+		resource: "rules", action: "delete", args: "ID", summary: "Delete a firewall rule",
+		setup: func(flags *flag.FlagSet) runFunc {
+			return func(env *environment, args []string) (interface{}, error) {
+				return remove(env, "rule", args[0], "/rules/"+args[0], func(client *path.Client) error {
+					return client.DeleteRule(args[0])
+				})
+			}
+		},
+	},
+	{
+		resource: "rate-limiters", action: "list", columns: rateLimiterColumns,
+		summary: "List the rate limiters, optionally only those matching a selector",
+		setup: func(flags *flag.FlagSet) runFunc {
+			selector := flags.String("selector", "", "label selector, such as \"team=edge,env!=staging\"")
+
+			return withClient(func(client *path.Client, args []string) (interface{}, error) {
+				parsed, err := path.ParseSelector(*selector)
+				if err != nil {
+					return nil, usagef("invalid selector: %v", err)
+				}
+
+				rateLimiters, err := client.GetRateLimiters()
+				if err != nil {
+					return nil, err
+				}
+
+				return rateLimiters.Select(parsed), nil
+			})
+		},
+	},
+	{
+		resource: "rate-limiters", action: "get", args: "ID", summary: "Show a rate limiter", columns: rateLimiterColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			return withClient(func(client *path.Client, args []string) (interface{}, error) {
+				return client.GetRateLimiter(args[0])
+			})
+		},
+	},
+	{
+		resource: "rate-limiters", action: "create", summary: "Create a rate limiter", columns: rateLimiterColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			var rateLimiter path.RateLimiter
+			flags.Var(&rateLimiter.PacketsPerSecond, "pps", "packets per second admitted, such as \"50 Kpps\" (required)")
+			flags.StringVar(&rateLimiter.Comment, "comment", "", "comment describing the rate limiter")
+
+			return func(env *environment, args []string) (interface{}, error) {
+				if rateLimiter.PacketsPerSecond == 0 {
+					return nil, usagef("-pps is required")
+				}
+
+				if env.dryRun {
+					return dryRun{
+						Action: "create rate limiter", Method: http.MethodPost, Path: "/rate_limiters", Body: rateLimiter,
+					}, nil
+				}
+
+				client, err := env.getClient()
+				if err != nil {
+					return nil, err
+				}
+
+				return client.CreateRateLimiter(rateLimiter)
+			}
+		},
+	},
+	{
+		resource: "rate-limiters", action: "update", args: "ID", summary: "Change the rate or comment of a rate limiter",
+		columns: rateLimiterColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			var pps path.PPS
+			flags.Var(&pps, "pps", "packets per second admitted, such as \"50 Kpps\"")
+			comment := flags.String("comment", "", "comment describing the rate limiter")
+
+			return func(env *environment, args []string) (interface{}, error) {
+				client, err := env.getClient()
+				if err != nil {
+					return nil, err
+				}
+
+				// Updates replace the whole rate limiter, so the fields which are not changed are taken from the current one
+				rateLimiter, err := client.GetRateLimiter(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				flags.Visit(func(set *flag.Flag) {
+					switch set.Name {
+					case "pps":
+						rateLimiter.PacketsPerSecond = pps
+					case "comment":
+						rateLimiter.Comment = *comment
+					}
+				})
+
+				if env.dryRun {
+					return dryRun{
+						Action: "update rate limiter", Method: http.MethodPost, Path: "/rate_limiters/" + args[0],
+						Body: rateLimiter,
+					}, nil
+				}
+
+				return client.UpdateRateLimiter(args[0], rateLimiter)
+			}
+		},
+	},
+	{
+		resource: "rate-limiters", action: "delete", args: "ID", summary: "Delete a rate limiter",
+		setup: func(flags *flag.FlagSet) runFunc {
+			return func(env *environment, args []string) (interface{}, error) {
+				return remove(env, "rate limiter", args[0], "/rate_limiters/"+args[0], func(client *path.Client) error {
+					return client.DeleteRateLimiter(args[0])
+				})
+			}
+		},
+	},
+	{
+		resource: "diversions", action: "list", summary: "List the diverted prefixes", columns: diversionColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			return withClient(func(client *path.Client, args []string) (interface{}, error) {
+				diversions, err := client.GetDiversions()
+				return diversions.Diversions, err
+			})
+		},
+	},
+	{
+		resource: "diversions", action: "get", args: "PREFIX", summary: "Show the diversion of a prefix",
+		columns: diversionColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			return withClient(func(client *path.Client, args []string) (interface{}, error) {
+				network, length, err := parsePrefix(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				return client.GetDiversion(network, length)
+			})
+		},
+	},
+	{
+		resource: "diversions", action: "create", args: "PREFIX", summary: "Manually divert a prefix",
+		columns: diversionColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			return func(env *environment, args []string) (interface{}, error) {
+				network, length, err := parsePrefix(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				if env.dryRun {
+					return dryRun{
+						Action: "create diversion", Method: http.MethodPost,
+						Path: fmt.Sprintf("/diversions/%s/%d", network, length),
+					}, nil
+				}
+
+				client, err := env.getClient()
+				if err != nil {
+					return nil, err
+				}
+
+				return client.CreateDiversion(args[0])
+			}
+		},
+	},
+	{
+		resource: "diversions", action: "delete", args: "PREFIX", summary: "Stop diverting a prefix",
+		setup: func(flags *flag.FlagSet) runFunc {
+			return func(env *environment, args []string) (interface{}, error) {
+				network, length, err := parsePrefix(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				endpoint := fmt.Sprintf("/diversions/%s/%d", network, length)
+				return remove(env, "diversion", args[0], endpoint, func(client *path.Client) error {
+					return client.DeleteDiversion(network, length)
+				})
+			}
+		},
+	},
+	{
+		resource: "filters", action: "list", summary: "List the deployed application filters", columns: filterColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			return withClient(func(client *path.Client, args []string) (interface{}, error) {
+				filters, err := client.GetFilters()
+				return filters.Filters, err
+			})
+		},
+	},
+	{
+		resource: "filters", action: "available", summary: "List the application filters available to the account",
+		columns: filterColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			return withClient(func(client *path.Client, args []string) (interface{}, error) {
+				filters, err := client.GetAvailableFilters()
+				return filters.Filters, err
+			})
+		},
+	},
+	{
+		resource: "filters", action: "options", summary: "Show the settings accepted by each filter type",
+		columns: filterOptionColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			return withClient(func(client *path.Client, args []string) (interface{}, error) {
+				options, err := client.GetFilterOptions()
+				return options.Filters, err
+			})
+		},
+	},
+	{
+		resource: "filters", action: "get", args: "TYPE ID", summary: "Show an application filter", columns: filterColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			return withClient(func(client *path.Client, args []string) (interface{}, error) {
+				return client.GetFilter(args[0], args[1])
+			})
+		},
+	},
+	{
+		resource: "filters", action: "create", args: "TYPE", summary: "Create an application filter",
+		columns: filterColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			settings := settingsFlag{}
+			flags.Var(settings, "set", "setting of the filter, as field=value (repeatable)")
+			noValidate := flags.Bool("no-validate", false, "skip checking the settings against the filter's schema")
+
+			return func(env *environment, args []string) (interface{}, error) {
+				return sendFilter(env, "create filter", args[0], "", path.FilterSettings(settings), *noValidate)
+			}
+		},
+	},
+	{
+		resource: "filters", action: "update", args: "TYPE ID", summary: "Change the settings of an application filter",
+		columns: filterColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			settings := settingsFlag{}
+			flags.Var(settings, "set", "setting of the filter, as field=value (repeatable)")
+			noValidate := flags.Bool("no-validate", false, "skip checking the settings against the filter's schema")
+
+			return func(env *environment, args []string) (interface{}, error) {
+				return sendFilter(env, "update filter", args[0], args[1], path.FilterSettings(settings), *noValidate)
+			}
+		},
+	},
+	{
+		resource: "filters", action: "delete", args: "TYPE ID", summary: "Delete an application filter",
+		setup: func(flags *flag.FlagSet) runFunc {
+			return func(env *environment, args []string) (interface{}, error) {
+				endpoint := fmt.Sprintf("/filters/%s/%s", args[0], args[1])
+				return remove(env, "filter", args[1], endpoint, func(client *path.Client) error {
+					return client.DeleteFilter(args[0], args[1])
+				})
+			}
+		},
+	},
+	{
+		resource: "attacks", action: "list", summary: "List the attacks detected against the account's hosts",
+		columns: attackColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			var query path.AttackHistoryQuery
+			var since, until timeFlag
+			flags.Var(&since, "since", "only attacks ongoing after this time, or this long ago such as \"24h\"")
+			flags.Var(&until, "until", "only attacks ongoing before this time, or this long ago")
+			flags.StringVar(&query.Host, "host", "", "only attacks against this host")
+			flags.StringVar(&query.Prefix, "prefix", "", "only attacks against hosts within this prefix")
+			flags.Var(&query.MinPeakBPS, "min-bps", "only attacks peaking at or above this bit rate, such as \"10 Gbps\"")
+			flags.Var(&query.MinPeakPPS, "min-pps", "only attacks peaking at or above this packet rate, such as \"1 Mpps\"")
+			flags.IntVar(&query.Limit, "limit", 0, "stop after this many attacks")
+
+			return withClient(func(client *path.Client, args []string) (interface{}, error) {
+				query.Since, query.Until = since.Time, until.Time
+
+				history, err := client.QueryAttackHistory(query)
+				return history.AttackHistory, err
+			})
+		},
+	},
+	{
+		resource: "announcements", action: "list", summary: "List the BGP announcements made for the account",
+		columns: announcementColumns,
+		setup: func(flags *flag.FlagSet) runFunc {
+			var filter path.AnnouncementFilter
+			var since, until timeFlag
+			flags.Var(&since, "since", "only announcements active after this time, or this long ago such as \"24h\"")
+			flags.Var(&until, "until", "only announcements active before this time, or this long ago")
+			flags.StringVar(&filter.Prefix, "prefix", "", "only announcements overlapping this address or prefix")
+			flags.BoolVar(&filter.ActiveOnly, "active", false, "only announcements which have not ended")
+
+			return withClient(func(client *path.Client, args []string) (interface{}, error) {
+				filter.Since, filter.Until = since.Time, until.Time
+
+				history, err := client.GetAnnouncementHistory()
+				if err != nil {
+					return nil, err
+				}
+
+				announcements, err := history.Filter(filter)
+				if err != nil {
+					return nil, usagef("invalid prefix: %v", err)
+				}
+
+				return announcements, nil
+			})
+		},
+	},
+}
+
+// tokenStatus describes the token of the selected profile without revealing it
+type tokenStatus struct {
+	TokenType string    `json:"token_type"`
+	Expiry    time.Time `json:"expiry"`
+	Scope     string    `json:"scope"`
+}
+
+func (tokenStatus) tableColumns() []string {
+	return []string{"token_type", "expiry", "scope"}
+}
+
+// sendFilter creates a filter, or updates it if an ID is given, after checking its settings against the schema of the
+// filter type
+func sendFilter(env *environment, action, filterType, filterID string, settings path.FilterSettings,
+	noValidate bool) (interface{}, error) {
+	client, err := env.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if !noValidate {
+		options, err := client.GetFilterOptions()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := options.ValidateFilter(filterType, settings); err != nil {
+			return nil, err
+		}
+	}
+
+	endpoint := "/filters/" + filterType
+	if filterID != "" {
+		endpoint += "/" + filterID
+	}
+
+	if env.dryRun {
+		return dryRun{Action: action, Method: http.MethodPost, Path: endpoint, Body: settings}, nil
+	}
+
+	if filterID == "" {
+		return client.CreateFilter(filterType, settings)
+	}
+
+	return client.UpdateFilter(filterType, filterID, settings)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// globalValueFlags are the global flags which take a separate value, which completion skips over
+var globalValueFlags = []string{"-o", "-output", "-profile", "-base-url", "-timeout", "-token-cache"}
+
+// commandFlags lists the flags accepted by a command, including the global flags, in alphabetical order
+func commandFlags(selected command) []string {
+	flags := flag.NewFlagSet(selected.resource+" "+selected.action, flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	addGlobalFlags(flags, &environment{})
+	selected.setup(flags)
+
+	var names []string
+	flags.VisitAll(func(defined *flag.Flag) {
+		names = append(names, "-"+defined.Name)
+	})
+
+	return names
+}
+
+// globalFlags lists the global flags in alphabetical order
+func globalFlags() []string {
+	flags := flag.NewFlagSet("pathctl", flag.ContinueOnError)
+	addGlobalFlags(flags, &environment{})
+
+	var names []string
+	flags.VisitAll(func(defined *flag.Flag) {
+		names = append(names, "-"+defined.Name)
+	})
+
+	return names
+}
+
+// writeCompletion prints the completion script for a shell
+func writeCompletion(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		_, err := io.WriteString(w, bashCompletion())
+		return err
+	case "zsh":
+		// zsh runs the bash completion through its compatibility layer
+		_, err := fmt.Fprintf(w, "#compdef pathctl\n\nautoload -U +X bashcompinit && bashcompinit\n\n%s", bashCompletion())
+		return err
+	case "fish":
+		_, err := io.WriteString(w, fishCompletion())
+		return err
+	default:
+		return usagef("unknown shell %q, expected bash, zsh or fish", shell)
+	}
+}
+
+// bashCompletion builds a bash completion script, completing resources, then actions, then the flags of the command
+func bashCompletion() string {
+	var script strings.Builder
+
+	script.WriteString("# bash completion for pathctl. Load it with: source <(pathctl completion bash)\n\n")
+	script.WriteString("_pathctl() {\n")
+	script.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" words=() i\n\n")
+	script.WriteString("    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	script.WriteString("        case \"${COMP_WORDS[i]}\" in\n")
+	fmt.Fprintf(&script, "            %s) ((i++)) ;;\n", strings.Join(bothDashes(globalValueFlags), "|"))
+	script.WriteString("            -*) ;;\n")
+	script.WriteString("            *) words+=(\"${COMP_WORDS[i]}\") ;;\n")
+	script.WriteString("        esac\n")
+	script.WriteString("    done\n\n")
+
+	script.WriteString("    local candidates\n")
+	script.WriteString("    case \"${#words[@]}\" in\n")
+	fmt.Fprintf(&script, "        0) candidates=\"%s\" ;;\n", strings.Join(append(resources(), "completion"), " "))
+	script.WriteString("        1)\n")
+	script.WriteString("            case \"${words[0]}\" in\n")
+	for _, resource := range resources() {
+		fmt.Fprintf(&script, "                %s) candidates=\"%s\" ;;\n", resource, strings.Join(actions(resource), " "))
+	}
+	script.WriteString("                completion) candidates=\"bash zsh fish\" ;;\n")
+	script.WriteString("            esac\n")
+	script.WriteString("            ;;\n")
+	script.WriteString("        *)\n")
+	script.WriteString("            case \"${words[0]} ${words[1]}\" in\n")
+	for _, selected := range commands {
+		fmt.Fprintf(&script, "                \"%s %s\") candidates=\"%s\" ;;\n", selected.resource, selected.action,
+			strings.Join(commandFlags(selected), " "))
+	}
+	script.WriteString("            esac\n")
+	script.WriteString("            ;;\n")
+	script.WriteString("    esac\n\n")
+
+	script.WriteString("    if [[ \"$cur\" == -* && \"${#words[@]}\" -lt 2 ]]; then\n")
+	fmt.Fprintf(&script, "        candidates=\"%s\"\n", strings.Join(globalFlags(), " "))
+	script.WriteString("    fi\n\n")
+	script.WriteString("    COMPREPLY=($(compgen -W \"$candidates\" -- \"$cur\"))\n")
+	script.WriteString("}\n\n")
+	script.WriteString("complete -F _pathctl pathctl\n")
+
+	return script.String()
+}
+
+// bothDashes adds the double-dash spelling of each flag, which the flag package also accepts
+func bothDashes(names []string) []string {
+	var spellings []string
+	for _, name := range names {
+		spellings = append(spellings, name, "-"+name)
+	}
+
+	return spellings
+}
+
+// fishCompletion builds a fish completion script
+func fishCompletion() string {
+	var script strings.Builder
+
+	script.WriteString("# fish completion for pathctl. Load it with: pathctl completion fish | source\n\n")
+	script.WriteString("complete -c pathctl -f\n")
+
+	for _, name := range globalFlags() {
+		fmt.Fprintf(&script, "complete -c pathctl -o %s\n", strings.TrimPrefix(name, "-"))
+	}
+
+	resourceNames := append(resources(), "completion")
+	fmt.Fprintf(&script, "complete -c pathctl -n \"not __fish_seen_subcommand_from %s\" -a \"%s\"\n",
+		strings.Join(resourceNames, " "), strings.Join(resourceNames, " "))
+
+	for _, resource := range resources() {
+		resourceActions := strings.Join(actions(resource), " ")
+		condition := fmt.Sprintf("__fish_seen_subcommand_from %s; and not __fish_seen_subcommand_from %s", resource,
+			resourceActions)
+		fmt.Fprintf(&script, "complete -c pathctl -n \"%s\" -a \"%s\"\n", condition, resourceActions)
+	}
+	script.WriteString("complete -c pathctl -n \"__fish_seen_subcommand_from completion\" -a \"bash zsh fish\"\n")
+
+	for _, selected := range commands {
+		condition := fmt.Sprintf("__fish_seen_subcommand_from %s; and __fish_seen_subcommand_from %s", selected.resource,
+			selected.action)
+		for _, name := range commandFlags(selected) {
+			fmt.Fprintf(&script, "complete -c pathctl -n \"%s\" -o %s\n", condition, strings.TrimPrefix(name, "-"))
+		}
+	}
+
+	return script.String()
+}
@@ -0,0 +1,326 @@
+// Command pathctl manages the rules, rate limiters, diversions, filters and history of a Path account from the command
+// line. The credentials are those of the profile selected with -profile, as resolved by path.LoadCredentialProfile.
+// Tokens are cached between runs in the file given by -token-cache, which defaults to PATH_TOKEN_CACHE or to
+// path.DefaultTokenCachePath.
+//
+// Usage:
+//
+//	pathctl [-o table|json|yaml] [-dry-run] [-profile name] [-base-url URL] [-timeout 30s] <resource> <action> \
+//		[flags] [arguments]
+//	pathctl completion bash|zsh|fish
+//
+// Run pathctl without arguments for the list of resources and actions. Rules cannot be updated, as the API has no
+// endpoint changing a rule; delete the rule and create it again instead. With -dry-run, commands which would change the
+// account print the request they would send instead; they may still read from the API, e.g. to validate settings. The
+// exit code tells apart usage errors (2), authentication failures (3), missing resources (4), invalid requests (5),
+// conflicts (6), rate limiting (7), server errors (8) and network errors (9) from other failures (1).
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/path-network/go-path"
+)
+
+// Exit codes of pathctl
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitAuth        = 3
+	exitNotFound    = 4
+	exitValidation  = 5
+	exitConflict    = 6
+	exitRateLimited = 7
+	exitServer      = 8
+	exitNetwork     = 9
+)
+
+// usageError reports a command line which could not be understood
+type usageError struct {
+	message string
+}
+
+func (err usageError) Error() string {
+	return err.message
+}
+
+// usagef builds a usageError
+func usagef(format string, args ...interface{}) error {
+	return usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// exitCode maps an error onto the exit code reporting it
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	var usage usageError
+	if errors.As(err, &usage) || errors.Is(err, flag.ErrHelp) {
+		return exitUsage
+	}
+
+	var validationError path.ValidationError
+	if errors.As(err, &validationError) {
+		return exitValidation
+	}
+
+	var responseError *path.ResponseError
+	if errors.As(err, &responseError) {
+		switch code := responseError.StatusCode; {
+		case code == http.StatusUnauthorized || code == http.StatusForbidden:
+			return exitAuth
+		case code == http.StatusNotFound:
+			return exitNotFound
+		case code == http.StatusBadRequest || code == http.StatusUnprocessableEntity:
+			return exitValidation
+		case code == http.StatusConflict:
+			return exitConflict
+		case code == http.StatusTooManyRequests:
+			return exitRateLimited
+		case code >= 500:
+			return exitServer
+		}
+	}
+
+	if errors.Is(err, path.ErrInvalidTokenRequest) {
+		return exitAuth
+	}
+
+	if errors.Is(err, path.ErrAlreadyDiverted) {
+		return exitConflict
+	}
+
+	var urlError *url.Error
+	var netError net.Error
+	if errors.As(err, &urlError) || errors.As(err, &netError) {
+		return exitNetwork
+	}
+
+	return exitError
+}
+
+// environment holds the global options of a run, and creates the client on first use so that usage errors, and dry
+// runs which need nothing from the API, never authenticate
+type environment struct {
+	output  string
+	dryRun  bool
+	profile string
+	baseURL string
+	timeout time.Duration
+	// tokenCache is the file caching tokens between runs, or empty to authenticate every time
+	tokenCache string
+	// debug dumps every exchange with the API, with secrets redacted, to stderr
+	debug  bool
+	stdout io.Writer
+	stderr io.Writer
+	client *path.Client
+}
+
+// getClient authenticates with the credentials of the selected profile
+func (env *environment) getClient() (*path.Client, error) {
+	if env.client != nil {
+		return env.client, nil
+	}
+
+	client, err := path.NewClientFromProfile(env.profile, env.clientOptions()...)
+	if err != nil {
+		return nil, err
+	}
+
+	env.client = &client
+
+	return env.client, nil
+}
+
+// clientOptions configures clients with the global options
+func (env *environment) clientOptions() []path.ClientOption {
+	options := []path.ClientOption{path.WithHTTPClient(&http.Client{Timeout: env.timeout})}
+	if env.baseURL != "" {
+		options = append(options, path.WithBaseURL(env.baseURL))
+	}
+
+	if env.tokenCache != "" {
+		options = append(options, path.WithTokenCache(path.FileTokenCache{Path: env.tokenCache}))
+	}
+	if env.debug {
+		options = append(options, path.WithDebug(env.stderr))
+	}
+
+	return options
+}
+
+// addGlobalFlags registers the global options, which are accepted before the resource as well as after the action
+func addGlobalFlags(flags *flag.FlagSet, env *environment) {
+	flags.StringVar(&env.output, "o", env.output, "output format: table, json or yaml")
+	flags.StringVar(&env.output, "output", env.output, "output format: table, json or yaml")
+	flags.BoolVar(&env.dryRun, "dry-run", env.dryRun, "print the changes which would be made instead of making them")
+	flags.StringVar(&env.profile, "profile", env.profile, "credential profile, if not the default")
+	flags.StringVar(&env.baseURL, "base-url", env.baseURL, "base URL of the API, if not the one of the profile")
+	flags.DurationVar(&env.timeout, "timeout", env.timeout, "timeout of each request to the API")
+	flags.BoolVar(&env.debug, "debug", env.debug, "print every request and response, with secrets redacted, to stderr")
+	flags.StringVar(&env.tokenCache, "token-cache", env.tokenCache,
+		"file caching tokens between runs, or empty to disable")
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes a command line and returns its exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	err := execute(args, stdout, stderr)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(stderr, "pathctl: %s\n", strings.TrimSpace(err.Error()))
+	}
+
+	return exitCode(err)
+}
+
+// execute parses a command line and runs the command it selects
+func execute(args []string, stdout, stderr io.Writer) error {
+	env := &environment{output: "table", timeout: 30 * time.Second, tokenCache: defaultTokenCache(), stdout: stdout,
+		stderr: stderr}
+
+	global := flag.NewFlagSet("pathctl", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	addGlobalFlags(global, env)
+	global.Usage = func() {
+		printUsage(stderr)
+		global.PrintDefaults()
+	}
+
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+
+	positional := global.Args()
+	if len(positional) == 0 {
+		global.Usage()
+		return usagef("a resource is required")
+	}
+
+	if positional[0] == "completion" {
+		if len(positional) != 2 {
+			return usagef("usage: pathctl completion bash|zsh|fish")
+		}
+		return writeCompletion(stdout, positional[1])
+	}
+
+	if len(positional) < 2 {
+		return usagef("an action is required for %s, one of: %s", positional[0], strings.Join(actions(positional[0]), ", "))
+	}
+
+	selected := findCommand(positional[0], positional[1])
+	if selected == nil {
+		if len(actions(positional[0])) == 0 {
+			return usagef("unknown resource %q", positional[0])
+		}
+		if positional[0] == "rules" && positional[1] == "update" {
+			return usagef("rules cannot be updated by the API, delete the rule and create it again instead")
+		}
+		return usagef("unknown action %q for %s, one of: %s", positional[1], positional[0],
+			strings.Join(actions(positional[0]), ", "))
+	}
+
+	flags := flag.NewFlagSet(fmt.Sprintf("pathctl %s %s", selected.resource, selected.action), flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	addGlobalFlags(flags, env)
+	runCommand := selected.setup(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: pathctl %s %s [flags] %s\n\n%s\n\n", selected.resource, selected.action,
+			selected.args, selected.summary)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(positional[2:]); err != nil {
+		return err
+	}
+
+	if got, expected := len(flags.Args()), len(strings.Fields(selected.args)); got != expected {
+		flags.Usage()
+		return usagef("%s %s expects %d arguments, got %d", selected.resource, selected.action, expected, got)
+	}
+
+	if env.output != "table" && env.output != "json" && env.output != "yaml" {
+		return usagef("unknown output format %q", env.output)
+	}
+
+	result, err := runCommand(env, flags.Args())
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(env.stdout, env.output, result, selected.columns)
+}
+
+// defaultTokenCache returns the token cache named by PATH_TOKEN_CACHE, or the default token cache of the user
+func defaultTokenCache() string {
+	if tokenCache, ok := os.LookupEnv("PATH_TOKEN_CACHE"); ok {
+		return tokenCache
+	}
+
+	tokenCache, _ := path.DefaultTokenCachePath()
+
+	return tokenCache
+}
+
+// printUsage lists the resources and their actions
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: pathctl [flags] <resource> <action> [flags] [arguments]\n\nResources and actions:\n")
+
+	for _, resource := range resources() {
+		fmt.Fprintf(w, "  %-14s %s\n", resource, strings.Join(actions(resource), ", "))
+	}
+
+	fmt.Fprintf(w, "  %-14s bash, zsh, fish\n\nGlobal flags:\n", "completion")
+}
+
+// resources lists the resources which have commands, in alphabetical order
+func resources() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, command := range commands {
+		if !seen[command.resource] {
+			seen[command.resource] = true
+			names = append(names, command.resource)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// actions lists the actions of a resource in the order they are declared
+func actions(resource string) []string {
+	var names []string
+	for _, command := range commands {
+		if command.resource == resource {
+			names = append(names, command.action)
+		}
+	}
+
+	return names
+}
+
+// findCommand returns the command for an action on a resource, or nil if there is none
+func findCommand(resource, action string) *command {
+	for i := range commands {
+		if commands[i].resource == resource && commands[i].action == action {
+			return &commands[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/path-network/go-path"
+)
+
+// TestMain authenticates with credentials from the environment rather than from a configuration file, and without
+// caching tokens
+func TestMain(m *testing.M) {
+	os.Setenv("PATH_TOKEN_CACHE", "")
+	os.Setenv(path.EnvConfig, filepath.Join(os.TempDir(), "pathctl-missing-config.json"))
+	os.Setenv(path.EnvUsername, "foo")
+	os.Setenv(path.EnvPassword, "bar")
+
+	os.Exit(m.Run())
+}
+
+// fakeAPIRoutes are the responses of the fake API, keyed by method and path. Missing routes respond with a 404
+var fakeAPIRoutes = map[string]string{
+	"POST /token": `{"access_token": "test", "token_type": "bearer"}`,
+	"GET /rules": `{"rules": [
+		{"id": "1", "destination": "203.0.113.5/32", "protocol": "udp", "dst_port": 53, "comment": "dns [team=edge]"},
+		{"id": "2", "destination": "203.0.113.6/32", "protocol": "tcp", "comment": "web [team=web]"}]}`,
+	"GET /rate_limiters/7": `{"id": "7", "packets_per_second": 5000, "comment": "syn [team=edge]"}`,
+}
+
+// newFakeAPI starts a fake of Path's API serving fakeAPIRoutes, and returns its URL along with the requests it received
+func newFakeAPI(t *testing.T) (string, *[]string, func()) {
+	var mutex sync.Mutex
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := fmt.Sprintf("%s %s", r.Method, r.URL.Path)
+
+		mutex.Lock()
+		requests = append(requests, route)
+		mutex.Unlock()
+
+		body, ok := fakeAPIRoutes[route]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+
+	return server.URL, &requests, server.Close
+}
+
+// TestRunList ensures that listed resources are filtered by selector and printed in each output format
+func TestRunList(t *testing.T) {
+	baseURL, _, closeAPI := newFakeAPI(t)
+	defer closeAPI()
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-base-url", baseURL, "rules", "list", "-selector", "team=edge"}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s\n", exitOK, code, stderr.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "ID") || !strings.HasPrefix(lines[1], "1 ") {
+		t.Errorf("Unexpected table: %q\n", stdout.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"rules", "list", "-o", "json", "-base-url", baseURL}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s\n", exitOK, code, stderr.String())
+	}
+
+	var rules []path.Rule
+	if err := json.Unmarshal(stdout.Bytes(), &rules); err != nil || len(rules) != 2 || rules[0].DstPort != 53 {
+		t.Errorf("Unexpected JSON output: %q (%v)\n", stdout.String(), err)
+	}
+}
+
+// TestRunDryRun ensures that dry runs print the change which would be made without making it
+func TestRunDryRun(t *testing.T) {
+	baseURL, requests, closeAPI := newFakeAPI(t)
+	defer closeAPI()
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"-base-url", baseURL, "-dry-run", "-o", "yaml", "rate-limiters", "update", "-pps", "50 Kpps", "7"}
+	if code := run(args, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s\n", exitOK, code, stderr.String())
+	}
+
+	expected := `action: update rate limiter
+body:
+  comment: syn [team=edge]
+  id: "7"
+  packets_per_second: 50000
+method: POST
+path: /rate_limiters/7
+`
+	if stdout.String() != expected {
+		t.Errorf("Expected %+v, got %+v\n", expected, stdout.String())
+	}
+
+	for _, request := range *requests {
+		if strings.HasPrefix(request, "POST /rate_limiters") {
+			t.Errorf("Unexpected request during a dry run: %s\n", request)
+		}
+	}
+
+	stdout.Reset()
+	if code := run([]string{"-dry-run", "diversions", "delete", "203.0.113.7/24"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s\n", exitOK, code, stderr.String())
+	}
+
+	if !strings.Contains(stdout.String(), "/diversions/203.0.113.0/24") {
+		t.Errorf("Unexpected dry run: %q\n", stdout.String())
+	}
+}
+
+// TestRunLogoutWithoutToken ensures that logging out without a cached token neither authenticates nor revokes anything
+func TestRunLogoutWithoutToken(t *testing.T) {
+	baseURL, requests, closeAPI := newFakeAPI(t)
+	defer closeAPI()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-base-url", baseURL, "auth", "logout"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s\n", exitOK, code, stderr.String())
+	}
+
+	if !strings.Contains(stdout.String(), "not logged in") || len(*requests) != 0 {
+		t.Errorf("Unexpected logout: %q after %+v\n", stdout.String(), *requests)
+	}
+}
+
+// TestExitCode ensures that errors are mapped onto the exit codes documented for scripts
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{nil, exitOK},
+		{usagef("unknown resource"), exitUsage},
+		{&path.ResponseError{StatusCode: http.StatusUnauthorized, Err: errors.New("bad credentials")}, exitAuth},
+		{path.AccessTokenRequest{ClientID: "id"}.Validate(), exitAuth},
+		{&path.ResponseError{StatusCode: http.StatusNotFound, Err: errors.New("not found")}, exitNotFound},
+		{&path.ResponseError{StatusCode: http.StatusUnprocessableEntity, Err: path.ValidationError{}}, exitValidation},
+		{path.ValidationError{}, exitValidation},
+		{&path.ResponseError{StatusCode: http.StatusTooManyRequests, Err: errors.New("slow down")}, exitRateLimited},
+		{&path.ResponseError{StatusCode: http.StatusBadGateway, Err: errors.New("bad gateway")}, exitServer},
+		{&url.Error{Op: "Get", URL: "https://api.path.net", Err: errors.New("connection refused")}, exitNetwork},
+		{errors.New("request was not acknowledged"), exitError},
+	}
+
+	for _, test := range tests {
+		if got := exitCode(test.err); got != test.expected {
+			t.Errorf("Expected %+v for %v, got %+v\n", test.expected, test.err, got)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	baseURL, _, closeAPI := newFakeAPI(t)
+	defer closeAPI()
+
+	if code := run([]string{"-base-url", baseURL, "rules", "get", "9"}, &stdout, &stderr); code != exitNotFound {
+		t.Errorf("Expected %+v, got %+v\n", exitNotFound, code)
+	}
+
+	if code := run([]string{"rules", "rename", "9"}, &stdout, &stderr); code != exitUsage {
+		t.Errorf("Expected %+v, got %+v\n", exitUsage, code)
+	}
+
+	stderr.Reset()
+	if code := run([]string{"rules", "update", "9"}, &stdout, &stderr); code != exitUsage ||
+		!strings.Contains(stderr.String(), "delete the rule and create it again") {
+		t.Errorf("Expected %+v with an explanation, got %+v: %s\n", exitUsage, code, stderr.String())
+	}
+}
+
+// TestYAMLDocument ensures that nested values are written as YAML blocks, quoting strings which would change type
+func TestYAMLDocument(t *testing.T) {
+	value, err := jsonValue(map[string]interface{}{
+		"filters": []interface{}{
+			map[string]interface{}{"name": "dns", "ports": []int{53, 5353}},
+			map[string]interface{}{"name": "true", "ports": []int{}},
+		},
+		"comment": "web: edge",
+		"empty":   map[string]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	expected := `comment: "web: edge"
+empty: {}
+filters:
+  - name: dns
+    ports:
+      - 53
+      - 5353
+  - name: "true"
+    ports: []
+`
+	if got := yamlDocument(value); got != expected {
+		t.Errorf("Expected %+v, got %+v\n", expected, got)
+	}
+}
+
+// TestCompletion ensures that completion scripts cover every resource and the flags of each command
+func TestCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"completion", shell}, &stdout, &stderr); code != exitOK {
+			t.Fatalf("Expected exit code %d for %s, got %d: %s\n", exitOK, shell, code, stderr.String())
+		}
+
+		for _, expected := range []string{"rate-limiters", "announcements", "dry-run", "rate-limiter"} {
+			if !strings.Contains(stdout.String(), expected) {
+				t.Errorf("Expected the %s completion to contain %q\n", shell, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// tabular is implemented by results which are shown with their own columns rather than those of the command
+type tabular interface {
+	tableColumns() []string
+}
+
+// writeOutput prints a result in the given format. Results are converted to their JSON encoding first, so that every
+// format shows the same field names as the API
+func writeOutput(w io.Writer, format string, result interface{}, columns []string) error {
+	if withColumns, ok := result.(tabular); ok {
+		columns = withColumns.tableColumns()
+	}
+
+	value, err := jsonValue(result)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case "yaml":
+		_, err := io.WriteString(w, yamlDocument(value))
+		return err
+	default:
+		return writeTable(w, value, columns)
+	}
+}
+
+// jsonValue converts a result into the generic values of its JSON encoding, keeping numbers exact
+func jsonValue(result interface{}) (interface{}, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	err = decoder.Decode(&value)
+
+	return value, err
+}
+
+// writeTable prints one row per object, or a single row for a lone object. Without columns, the keys of the objects
+// are used in alphabetical order
+func writeTable(w io.Writer, value interface{}, columns []string) error {
+	var rows []interface{}
+	switch typed := value.(type) {
+	case []interface{}:
+		rows = typed
+	case nil:
+	default:
+		rows = []interface{}{typed}
+	}
+
+	if len(columns) == 0 {
+		columns = objectKeys(rows)
+	}
+
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = strings.ToUpper(strings.Replace(column, ".", " ", -1))
+	}
+	fmt.Fprintln(table, strings.Join(headers, "\t"))
+
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cells[i] = tableCell(lookup(row, column))
+		}
+		fmt.Fprintln(table, strings.Join(cells, "\t"))
+	}
+
+	return table.Flush()
+}
+
+// objectKeys returns every key of the given objects in alphabetical order
+func objectKeys(rows []interface{}) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, row := range rows {
+		object, _ := row.(map[string]interface{})
+		for key := range object {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// lookup follows a dotted path such as "peak_bps.value" into nested objects
+func lookup(value interface{}, column string) interface{} {
+	for _, key := range strings.Split(column, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+
+	return value
+}
+
+// tableCell formats a value for a table, showing nested values as compact JSON and missing values as "-"
+func tableCell(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return "-"
+	case string:
+		if typed == "" {
+			return "-"
+		}
+		return strings.Replace(typed, "\t", " ", -1)
+	case json.Number:
+		return typed.String()
+	case bool:
+		return strconv.FormatBool(typed)
+	default:
+		data, _ := json.Marshal(typed)
+		return string(data)
+	}
+}
+
+// yamlDocument encodes a generic JSON value as a YAML document. Object keys are written in alphabetical order
+func yamlDocument(value interface{}) string {
+	var out strings.Builder
+	writeYAML(&out, value, 0)
+
+	text := out.String()
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	return text
+}
+
+// writeYAML writes a value at the given indentation. Scalars and empty collections are written inline, without a
+// trailing newline, while objects and lists are written as blocks of lines
+func writeYAML(out *strings.Builder, value interface{}, indent int) {
+	prefix := strings.Repeat("  ", indent)
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if len(typed) == 0 {
+			out.WriteString("{}")
+			return
+		}
+
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			out.WriteString(prefix + yamlScalar(key) + ":")
+			writeYAMLChild(out, typed[key], indent+1)
+		}
+
+	case []interface{}:
+		if len(typed) == 0 {
+			out.WriteString("[]")
+			return
+		}
+
+		for _, element := range typed {
+			if isYAMLBlock(element) {
+				// The first line of the element follows the dash instead of its own indentation
+				var block strings.Builder
+				writeYAML(&block, element, indent+1)
+				out.WriteString(prefix + "- " + strings.TrimPrefix(block.String(), prefix+"  "))
+				continue
+			}
+
+			out.WriteString(prefix + "- ")
+			writeYAML(out, element, indent+1)
+			out.WriteString("\n")
+		}
+
+	default:
+		out.WriteString(yamlScalar(typed))
+	}
+}
+
+// writeYAMLChild writes the value of an object key, either after the key or on the following lines
+func writeYAMLChild(out *strings.Builder, value interface{}, indent int) {
+	if isYAMLBlock(value) {
+		out.WriteString("\n")
+		writeYAML(out, value, indent)
+		return
+	}
+
+	out.WriteString(" ")
+	writeYAML(out, value, indent)
+	out.WriteString("\n")
+}
+
+// isYAMLBlock reports whether a value is written as a block of lines rather than inline
+func isYAMLBlock(value interface{}) bool {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		return len(typed) > 0
+	case []interface{}:
+		return len(typed) > 0
+	default:
+		return false
+	}
+}
+
+// yamlScalar formats a scalar, quoting strings which YAML would otherwise read as another type or misparse
+func yamlScalar(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(typed)
+	case json.Number:
+		return typed.String()
+	case string:
+		if yamlNeedsQuotes(typed) {
+			data, _ := json.Marshal(typed)
+			return string(data)
+		}
+		return typed
+	default:
+		return fmt.Sprint(typed)
+	}
+}
+
+// yamlNeedsQuotes reports whether a string must be quoted to be read back as the same string
+func yamlNeedsQuotes(text string) bool {
+	if text == "" || strings.TrimSpace(text) != text {
+		return true
+	}
+
+	switch strings.ToLower(text) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return true
+	}
+
+	if _, err := strconv.ParseFloat(text, 64); err == nil {
+		return true
+	}
+
+	if strings.ContainsAny(text[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+
+	for _, c := range text {
+		if c < ' ' || c == 0x7f {
+			return true
+		}
+	}
+
+	return strings.HasSuffix(text, ":") || strings.Contains(text, ": ") || strings.Contains(text, " #")
+}
@@ -0,0 +1,301 @@
+package path
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Environment variables read by LoadCredentialProfile. The credential variables override the values of the selected
+// profile
+const (
+	// EnvConfig holds the path of the configuration file, replacing DefaultConfigPath
+	EnvConfig = "PATH_CONFIG"
+	// EnvProfile holds the name of the profile to use when none is given
+	EnvProfile          = "PATH_PROFILE"
+	EnvBaseURL          = "PATH_BASE_URL"
+	EnvUsername         = "PATH_USERNAME"
+	EnvPassword         = "PATH_PASSWORD"
+	EnvClientID         = "PATH_CLIENT_ID"
+	EnvClientSecret     = "PATH_CLIENT_SECRET"
+	EnvScope            = "PATH_SCOPE"
+	EnvCredentialHelper = "PATH_CREDENTIAL_HELPER"
+)
+
+// DefaultProfileName is the profile used when none is given and the configuration file has no default profile
+const DefaultProfileName = "default"
+
+// CredentialConfig is the configuration file shared by the tools built on this package. It is stored as JSON:
+//
+//	{
+//		"default_profile": "production",
+//		"profiles": {
+//			"production": {"client_id": "...", "credential_helper": ["pass-path-helper", "production"]},
+//			"staging": {"base_url": "https://staging.api.path.net", "username": "ops", "password": "..."}
+//		}
+//	}
+type CredentialConfig struct {
+	// DefaultProfile is the profile used when none is given
+	DefaultProfile string                       `json:"default_profile,omitempty"`
+	Profiles       map[string]CredentialProfile `json:"profiles"`
+}
+
+// CredentialProfile holds the settings needed to authenticate against an account: either a username and password, or a
+// client ID and secret
+type CredentialProfile struct {
+	// Name of the profile in the configuration file, which is not stored in the profile itself
+	Name string `json:"-"`
+	// BaseURL of the API. If empty, the client's default is used
+	BaseURL      string `json:"base_url,omitempty"`
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	// CredentialHelper is a command, given as its arguments, which is run to obtain the credentials so that secrets do
+	// not have to be stored in the configuration file. The word "get" is appended to its arguments, and it is given a
+	// JSON object holding "profile" and "base_url" on its standard input. It must print a JSON object on its standard
+	// output, which may hold any of "username", "password", "client_id", "client_secret" and "scope". The non-empty
+	// values it prints replace those of the profile
+	CredentialHelper []string `json:"credential_helper,omitempty"`
+}
+
+// DefaultConfigPath returns the location of the configuration file within the user's configuration directory, such
+// as ~/.config/path/config.json on Linux
+func DefaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "path", "config.json"), nil
+}
+
+// LoadCredentialConfig reads a configuration file
+func LoadCredentialConfig(filename string) (CredentialConfig, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return CredentialConfig{}, err
+	}
+
+	var config CredentialConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return CredentialConfig{}, fmt.Errorf("invalid configuration file %s: %w", filename, err)
+	}
+
+	return config, nil
+}
+
+// Profile returns the profile with the given name, or the default profile if the name is empty
+func (config CredentialConfig) Profile(name string) (CredentialProfile, error) {
+	if name == "" {
+		name = config.DefaultProfile
+	}
+	if name == "" {
+		name = DefaultProfileName
+	}
+
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return CredentialProfile{}, fmt.Errorf("unknown profile %q", name)
+	}
+
+	profile.Name = name
+
+	return profile, nil
+}
+
+// LoadCredentialProfile resolves the credentials of a profile. The profile is read from the file named by PATH_CONFIG,
+// or from DefaultConfigPath, then the PATH_* environment variables override its settings, and finally its credential
+// helper is run, if it has one. The helper completes the credentials of the file, but never overrides those set in the
+// environment.
+//
+// If name is empty, PATH_PROFILE names the profile, then the file's default profile. A missing configuration file or
+// profile is only an error if the profile was named, so that the environment variables alone are enough to
+// authenticate
+func LoadCredentialProfile(name string) (CredentialProfile, error) {
+	return loadCredentialProfile(name, os.Getenv)
+}
+
+// loadCredentialProfile implements LoadCredentialProfile, reading the environment through getenv
+func loadCredentialProfile(name string, getenv func(string) string) (CredentialProfile, error) {
+	if name == "" {
+		name = getenv(EnvProfile)
+	}
+
+	filename := getenv(EnvConfig)
+	if filename == "" {
+		var err error
+		if filename, err = DefaultConfigPath(); err != nil && name != "" {
+			return CredentialProfile{}, err
+		}
+	}
+
+	var profile CredentialProfile
+	if filename != "" {
+		config, err := LoadCredentialConfig(filename)
+		switch {
+		case err == nil:
+			profile, err = config.Profile(name)
+			if err != nil && (name != "" || config.DefaultProfile != "") {
+				return CredentialProfile{}, err
+			}
+		case !os.IsNotExist(err) || name != "":
+			return CredentialProfile{}, err
+		}
+	}
+
+	if profile.Name == "" {
+		profile.Name = name
+	}
+
+	profile.applyEnvironment(getenv)
+
+	if len(profile.CredentialHelper) > 0 {
+		if err := profile.runCredentialHelper(getenv); err != nil {
+			return CredentialProfile{}, err
+		}
+	}
+
+	return profile, nil
+}
+
+// applyEnvironment replaces the settings of the profile with those of the environment variables which are set
+func (profile *CredentialProfile) applyEnvironment(getenv func(string) string) {
+	overrides := []struct {
+		name  string
+		value *string
+	}{
+		{EnvBaseURL, &profile.BaseURL},
+		{EnvUsername, &profile.Username},
+		{EnvPassword, &profile.Password},
+		{EnvClientID, &profile.ClientID},
+		{EnvClientSecret, &profile.ClientSecret},
+		{EnvScope, &profile.Scope},
+	}
+
+	for _, override := range overrides {
+		if value := getenv(override.name); value != "" {
+			*override.value = value
+		}
+	}
+
+	if helper := getenv(EnvCredentialHelper); helper != "" {
+		profile.CredentialHelper = strings.Fields(helper)
+	}
+}
+
+// credentialHelperRequest is written to the standard input of a credential helper
+type credentialHelperRequest struct {
+	Profile string `json:"profile"`
+	BaseURL string `json:"base_url"`
+}
+
+// credentialHelperResponse is read from the standard output of a credential helper
+type credentialHelperResponse struct {
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Scope        string `json:"scope"`
+}
+
+// runCredentialHelper runs the credential helper of the profile, and stores the credentials it prints except those
+// set in the environment, read through getenv
+func (profile *CredentialProfile) runCredentialHelper(getenv func(string) string) error {
+	request, err := json.Marshal(credentialHelperRequest{Profile: profile.Name, BaseURL: profile.BaseURL})
+	if err != nil {
+		return err
+	}
+
+	var stdout, stderr bytes.Buffer
+	helper := exec.Command(profile.CredentialHelper[0], append(profile.CredentialHelper[1:], "get")...)
+	helper.Stdin = bytes.NewReader(request)
+	helper.Stdout = &stdout
+	helper.Stderr = &stderr
+
+	if err := helper.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("credential helper %s failed: %w: %s", profile.CredentialHelper[0], err, message)
+		}
+		return fmt.Errorf("credential helper %s failed: %w", profile.CredentialHelper[0], err)
+	}
+
+	var response credentialHelperResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return fmt.Errorf("credential helper %s printed invalid credentials: %w", profile.CredentialHelper[0], err)
+	}
+
+	credentials := []struct {
+		value  string
+		target *string
+		env    string
+	}{
+		{response.Username, &profile.Username, EnvUsername},
+		{response.Password, &profile.Password, EnvPassword},
+		{response.ClientID, &profile.ClientID, EnvClientID},
+		{response.ClientSecret, &profile.ClientSecret, EnvClientSecret},
+		{response.Scope, &profile.Scope, EnvScope},
+	}
+
+	for _, credential := range credentials {
+		if credential.value != "" && getenv(credential.env) == "" {
+			*credential.target = credential.value
+		}
+	}
+
+	return nil
+}
+
+// TokenRequest returns the request made to the /token endpoint for the profile's credentials
+func (profile CredentialProfile) TokenRequest() AccessTokenRequest {
+	return AccessTokenRequest{
+		Username:     profile.Username,
+		Password:     profile.Password,
+		Scope:        profile.Scope,
+		ClientID:     profile.ClientID,
+		ClientSecret: profile.ClientSecret,
+	}
+}
+
+// NewClient creates a client authenticated with the profile's credentials and pointed at its base URL. The options
+// are applied after the profile's, so they may e.g. replace its base URL
+func (profile CredentialProfile) NewClient(options ...ClientOption) (Client, error) {
+	if profile.Username == "" && profile.ClientID == "" {
+		return Client{}, errNoCredentials(profile.Name)
+	}
+
+	var profileOptions []ClientOption
+	if profile.BaseURL != "" {
+		profileOptions = append(profileOptions, WithBaseURL(profile.BaseURL))
+	}
+
+	return NewClient(profile.TokenRequest(), append(profileOptions, options...)...)
+}
+
+// errNoCredentials reports a profile holding neither a username nor a client ID
+func errNoCredentials(profileName string) error {
+	if profileName == "" {
+		return errors.New("no credentials: set PATH_USERNAME and PATH_PASSWORD, or PATH_CLIENT_ID and " +
+			"PATH_CLIENT_SECRET, or configure a profile")
+	}
+
+	return fmt.Errorf("no credentials in profile %q", profileName)
+}
+
+// NewClientFromProfile loads a profile with LoadCredentialProfile and creates a client for it, as
+// CredentialProfile.NewClient does
+func NewClientFromProfile(name string, options ...ClientOption) (Client, error) {
+	profile, err := LoadCredentialProfile(name)
+	if err != nil {
+		return Client{}, err
+	}
+
+	return profile.NewClient(options...)
+}
@@ -0,0 +1,112 @@
+package path
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCredentialHelperProcess is run as a credential helper by TestLoadCredentialProfile, rather than as a test. It
+// returns a password derived from the profile it is asked about
+func TestCredentialHelperProcess(t *testing.T) {
+	if os.Getenv("GO_PATH_CREDENTIAL_HELPER") != "1" {
+		return
+	}
+
+	var request credentialHelperRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil || os.Args[len(os.Args)-1] != "get" {
+		os.Exit(1)
+	}
+
+	fmt.Printf(`{"password": "secret-for-%s", "scope": "%s"}`, request.Profile, request.BaseURL)
+	os.Exit(0)
+}
+
+// TestLoadCredentialProfile ensures that profiles are selected from the configuration file, overridden by the
+// environment and completed by their credential helper
+func TestLoadCredentialProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	helper, _ := json.Marshal([]string{os.Args[0], "-test.run=TestCredentialHelperProcess", "--"})
+	config := fmt.Sprintf(`{"default_profile": "production", "profiles": {
+		"production": {"client_id": "prod", "client_secret": "prod-secret"},
+		"staging": {"base_url": "https://staging.example", "username": "ops", "credential_helper": %s}}}`, helper)
+
+	filename := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(filename, []byte(config), 0600); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	os.Setenv("GO_PATH_CREDENTIAL_HELPER", "1")
+	defer os.Unsetenv("GO_PATH_CREDENTIAL_HELPER")
+
+	environment := map[string]string{EnvConfig: filename}
+	getenv := func(name string) string {
+		return environment[name]
+	}
+
+	profile, err := loadCredentialProfile("", getenv)
+	if err != nil || profile.Name != "production" || profile.TokenRequest().ClientSecret != "prod-secret" {
+		t.Errorf("Unexpected default profile: %+v (%v)\n", profile, err)
+	}
+
+	environment[EnvProfile] = "staging"
+	environment[EnvUsername] = "admin"
+	profile, err = loadCredentialProfile("", getenv)
+	expected := CredentialProfile{
+		Name: "staging", BaseURL: "https://staging.example", Username: "admin", Password: "secret-for-staging",
+		Scope: "https://staging.example", CredentialHelper: profile.CredentialHelper,
+	}
+	if err != nil || fmt.Sprint(profile) != fmt.Sprint(expected) {
+		t.Errorf("Expected %+v, got %+v (%v)\n", expected, profile, err)
+	}
+
+	// The environment takes precedence over the credential helper
+	environment[EnvPassword] = "secret-from-environment"
+	profile, err = loadCredentialProfile("", getenv)
+	if err != nil || profile.Password != "secret-from-environment" || profile.Scope != "https://staging.example" {
+		t.Errorf("Expected %+v, got %+v (%v)\n", "secret-from-environment", profile.Password, err)
+	}
+	delete(environment, EnvPassword)
+
+	if _, err := loadCredentialProfile("development", getenv); err == nil {
+		t.Errorf("Expected an error for an unknown profile\n")
+	}
+
+	// Without a configuration file, the environment alone is enough
+	environment = map[string]string{EnvConfig: filepath.Join(dir, "missing.json"), EnvClientID: "id"}
+	profile, err = loadCredentialProfile("", getenv)
+	if err != nil || profile.ClientID != "id" {
+		t.Errorf("Unexpected profile: %+v (%v)\n", profile, err)
+	}
+}
+
+// TestCredentialProfileNewClient ensures that clients are pointed at the base URL of their profile and authenticated
+// with its credentials
+func TestCredentialProfileNewClient(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"POST /token": {http.StatusOK, `{"access_token": "test", "token_type": "bearer"}`},
+	})
+	defer closeAPI()
+
+	profile := CredentialProfile{BaseURL: client.baseURL, ClientID: "id", ClientSecret: "secret"}
+	if _, err := profile.NewClient(WithHTTPClient(client.httpClient)); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	if len(api.requests) != 1 || api.requests[0] != "POST /token" {
+		t.Errorf("Unexpected requests: %+v\n", api.requests)
+	}
+
+	if _, err := (CredentialProfile{Name: "empty"}).NewClient(); err == nil {
+		t.Errorf("Expected an error for a profile without credentials\n")
+	}
+}
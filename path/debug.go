@@ -0,0 +1,90 @@
+package path
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"regexp"
+	"time"
+)
+
+// Logger receives a line for every request made by a client. *log.Logger satisfies it
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// WithLogger makes the client log the method, URL, outcome and duration of every request it makes
+func WithLogger(logger Logger) ClientOption {
+	return func(client *Client) {
+		client.logger = logger
+	}
+}
+
+// WithDebug makes the client write every request and response it exchanges with the API to w, including their
+// headers and bodies. The Authorization header, and password, secret and token fields of forms and JSON bodies are
+// redacted, so the dump can be shared. Bodies are read into memory to be dumped
+func WithDebug(w io.Writer) ClientOption {
+	return func(client *Client) {
+		client.debug = w
+	}
+}
+
+// Patterns of the secrets redacted from debug dumps
+var (
+	authorizationHeader = regexp.MustCompile(`(?im)^(Authorization:[ \t]*)[^\r\n]*`)
+	secretFormField     = regexp.MustCompile(
+		`(^|&)((?:password|old_password|new_password|client_secret|token|access_token|refresh_token)=)[^&\r\n]*`)
+	secretJSONField = regexp.MustCompile(
+		`("(?:password|old_password|new_password|client_secret|token|access_token|refresh_token)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+)
+
+// redactDump hides the secrets of a dumped request or response
+func redactDump(dump []byte) []byte {
+	head, body := dump, []byte(nil)
+	if i := bytes.Index(dump, []byte("\r\n\r\n")); i != -1 {
+		head, body = dump[:i+4], dump[i+4:]
+	}
+
+	head = authorizationHeader.ReplaceAll(head, []byte("${1}"+redactedSecret))
+	body = secretFormField.ReplaceAll(body, []byte("${1}${2}"+redactedSecret))
+	body = secretJSONField.ReplaceAll(body, []byte(`${1}"`+redactedSecret+`"`))
+
+	return append(append([]byte(nil), head...), body...)
+}
+
+// dumpRequest writes the redacted request to the client's debug writer
+func (client *Client) dumpRequest(req *http.Request) {
+	dump, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		fmt.Fprintf(client.debug, "> %s %s: error dumping the request: %v\n\n", req.Method, req.URL, err)
+		return
+	}
+
+	fmt.Fprintf(client.debug, "> %s\n\n", bytes.TrimSpace(redactDump(dump)))
+}
+
+// dumpResponse writes the redacted response to the client's debug writer. The body of the response is replaced with a
+// copy, so that it can still be read by the caller
+func (client *Client) dumpResponse(resp *http.Response) {
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		fmt.Fprintf(client.debug, "< %s: error dumping the response: %v\n\n", resp.Status, err)
+		return
+	}
+
+	fmt.Fprintf(client.debug, "< %s\n\n", bytes.TrimSpace(redactDump(dump)))
+}
+
+// logRequest logs the outcome of a request to the client's logger
+func (client *Client) logRequest(req *http.Request, resp *http.Response, err error, duration time.Duration) {
+	duration = duration.Round(time.Millisecond)
+
+	if err != nil {
+		client.logger.Printf("path: %s %s failed after %s: %v", req.Method, req.URL, duration, err)
+		return
+	}
+
+	client.logger.Printf("path: %s %s: %s in %s", req.Method, req.URL, resp.Status, duration)
+}
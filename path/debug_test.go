@@ -0,0 +1,56 @@
+package path
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// recordingLogger is a Logger which keeps the lines it receives
+type recordingLogger struct {
+	lines []string
+}
+
+func (logger *recordingLogger) Printf(format string, args ...interface{}) {
+	logger.lines = append(logger.lines, fmt.Sprintf(format, args...))
+}
+
+// TestWithDebug ensures that exchanges are dumped with their credentials and tokens redacted, and logged line by line
+func TestWithDebug(t *testing.T) {
+	client, _, closeAPI := newTestClient(t, map[string]mockResponse{
+		"POST /token": {http.StatusOK, `{"access_token": "eyJhbGciOi", "token_type": "bearer"}`},
+		"GET /rules":  {http.StatusOK, `{"rules": []}`},
+	})
+	defer closeAPI()
+
+	var dump bytes.Buffer
+	logger := &recordingLogger{}
+	debugClient, err := NewClient(AccessTokenRequest{Username: "foo", Password: "hunter2"},
+		WithBaseURL(client.baseURL), WithHTTPClient(client.httpClient), WithDebug(&dump), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	if _, err := debugClient.GetRules(); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	for _, secret := range []string{"hunter2", "eyJhbGciOi"} {
+		if strings.Contains(dump.String(), secret) {
+			t.Errorf("Expected %q to be redacted from the dump:\n%s\n", secret, dump.String())
+		}
+	}
+
+	for _, expected := range []string{"password=[REDACTED]", `"access_token": "[REDACTED]"`,
+		"Authorization: [REDACTED]", `{"rules": []}`} {
+		if !strings.Contains(dump.String(), expected) {
+			t.Errorf("Expected the dump to contain %q:\n%s\n", expected, dump.String())
+		}
+	}
+
+	if len(logger.lines) != 2 || !strings.Contains(logger.lines[1], "GET "+client.baseURL+"/rules: 200 OK") {
+		t.Errorf("Unexpected log lines: %+v\n", logger.lines)
+	}
+}
@@ -0,0 +1,288 @@
+package path
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DiversionEventType identifies the kind of change observed by a DiversionWatcher
+type DiversionEventType string
+
+// Event types emitted by a DiversionWatcher
+const (
+	// A subnet started being diverted
+	DiversionStarted DiversionEventType = "diversion_started"
+	// A subnet is no longer diverted
+	DiversionEnded DiversionEventType = "diversion_ended"
+	// A host within a diverted subnet is reported as under attack
+	HostUnderAttack DiversionEventType = "host_under_attack"
+	// A host is no longer reported as under attack
+	HostRecovered DiversionEventType = "host_recovered"
+)
+
+// DiversionEvent describes a change in the diversions of an account
+type DiversionEvent struct {
+	Type   DiversionEventType
+	Subnet string
+	// Whether the diversion was performed manually
+	Manual bool
+	// Host and Reason are only set for HostUnderAttack and HostRecovered events
+	Host   string
+	Reason AttackReason
+	// The time at which the change was observed
+	Time time.Time
+}
+
+// DiversionState is a snapshot of the diversions of an account, keyed by subnet
+type DiversionState map[string]Diversion
+
+// NewDiversionState creates a snapshot of the given diversions
+func NewDiversionState(diversions Diversions) DiversionState {
+	state := DiversionState{}
+	for _, diversion := range diversions.Diversions {
+		state[diversion.Subnet] = diversion
+	}
+
+	return state
+}
+
+// DiffDiversionStates returns the events which lead from the previous to the current state. Events are ordered by
+// subnet, with a diversion starting before its hosts are reported under attack, and hosts recovering before their
+// diversion ends
+func DiffDiversionStates(previous, current DiversionState, now time.Time) []DiversionEvent {
+	subnets := map[string]bool{}
+	for subnet := range previous {
+		subnets[subnet] = true
+	}
+	for subnet := range current {
+		subnets[subnet] = true
+	}
+
+	sortedSubnets := make([]string, 0, len(subnets))
+	for subnet := range subnets {
+		sortedSubnets = append(sortedSubnets, subnet)
+	}
+	sort.Strings(sortedSubnets)
+
+	var events []DiversionEvent
+	for _, subnet := range sortedSubnets {
+		before, wasDiverted := previous[subnet]
+		after, isDiverted := current[subnet]
+
+		if !wasDiverted {
+			events = append(events, DiversionEvent{Type: DiversionStarted, Subnet: subnet, Manual: after.Manual, Time: now})
+		}
+
+		hostsBefore := hostsUnderAttack(before)
+		hostsAfter := hostsUnderAttack(after)
+
+		for _, attack := range after.UnderAttack {
+			if _, ok := hostsBefore[attack.Host]; !ok {
+				events = append(events, DiversionEvent{
+					Type: HostUnderAttack, Subnet: subnet, Manual: after.Manual, Host: attack.Host, Reason: attack.Reason, Time: now,
+				})
+			}
+		}
+
+		for _, attack := range before.UnderAttack {
+			if _, ok := hostsAfter[attack.Host]; !ok {
+				events = append(events, DiversionEvent{
+					Type: HostRecovered, Subnet: subnet, Manual: before.Manual, Host: attack.Host, Reason: attack.Reason, Time: now,
+				})
+			}
+		}
+
+		if !isDiverted {
+			events = append(events, DiversionEvent{Type: DiversionEnded, Subnet: subnet, Manual: before.Manual, Time: now})
+		}
+	}
+
+	return events
+}
+
+// hostsUnderAttack indexes the hosts reported under attack within a diversion
+func hostsUnderAttack(diversion Diversion) map[string]UnderAttack {
+	hosts := map[string]UnderAttack{}
+	for _, attack := range diversion.UnderAttack {
+		hosts[attack.Host] = attack
+	}
+
+	return hosts
+}
+
+// DiversionStateStore persists the last state seen by a DiversionWatcher, so that events are not emitted again after
+// the watcher restarts
+type DiversionStateStore interface {
+	// Load returns the last saved state, or an empty state if none was saved yet
+	Load() (DiversionState, error)
+	Save(state DiversionState) error
+}
+
+// FileDiversionStateStore stores the state of a DiversionWatcher as JSON in a file
+type FileDiversionStateStore struct {
+	Path string
+}
+
+// Load reads the state from the file. A missing file yields an empty state
+func (store FileDiversionStateStore) Load() (DiversionState, error) {
+	data, err := ioutil.ReadFile(store.Path)
+	if os.IsNotExist(err) {
+		return DiversionState{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var state DiversionState
+	err = json.Unmarshal(data, &state)
+
+	return state, err
+}
+
+// Save writes the state to a temporary file which then replaces the previous one, so that an interrupted write never
+// leaves a corrupted state behind
+func (store FileDiversionStateStore) Save(state DiversionState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(store.Path), filepath.Base(store.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), store.Path)
+}
+
+// DefaultWatchInterval is the time between polls made by Watch and Events when Interval is not positive
+const DefaultWatchInterval = time.Minute
+
+// DiversionWatcher polls the diversions of an account and emits an event for every change between successive polls
+type DiversionWatcher struct {
+	Client *Client
+	// Interval is the time between polls made by Watch and Events. If it is not positive, DefaultWatchInterval is used
+	Interval time.Duration
+	// MaxBackoff caps the time between polls while requests are failing. The interval is doubled after every
+	// consecutive failure. If zero, the interval is not increased
+	MaxBackoff time.Duration
+	// Store persists the last state seen. If nil, the watcher starts from an empty state, so every subnet diverted at
+	// the time of the first poll is reported as started
+	Store DiversionStateStore
+	// Clock is used to timestamp events. If nil, the system clock is used
+	Clock Clock
+	// OnError is called with every error encountered while watching, if it is not nil
+	OnError func(error)
+
+	state DiversionState
+}
+
+// Poll fetches the current diversions and returns the events since the previous poll. The new state is saved before
+// the events are returned, so an event is never emitted twice, even across restarts
+func (watcher *DiversionWatcher) Poll() ([]DiversionEvent, error) {
+	if watcher.state == nil {
+		watcher.state = DiversionState{}
+
+		if watcher.Store != nil {
+			state, err := watcher.Store.Load()
+			if err != nil {
+				watcher.state = nil
+				return nil, err
+			}
+
+			if state != nil {
+				watcher.state = state
+			}
+		}
+	}
+
+	diversions, err := watcher.Client.GetDiversions()
+	if err != nil {
+		return nil, err
+	}
+
+	current := NewDiversionState(diversions)
+	events := DiffDiversionStates(watcher.state, current, clockOrSystem(watcher.Clock).Now())
+
+	if watcher.Store != nil && len(events) > 0 {
+		if err := watcher.Store.Save(current); err != nil {
+			return nil, err
+		}
+	}
+
+	watcher.state = current
+
+	return events, nil
+}
+
+// Watch polls until ctx is cancelled and passes every event to handler
+func (watcher *DiversionWatcher) Watch(ctx context.Context, handler func(DiversionEvent)) error {
+	interval := watcher.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	delay := interval
+	for {
+		events, err := watcher.Poll()
+		if err != nil {
+			if watcher.OnError != nil {
+				watcher.OnError(err)
+			}
+
+			if delay *= 2; delay > watcher.MaxBackoff {
+				delay = watcher.MaxBackoff
+			}
+			if delay < interval {
+				delay = interval
+			}
+		} else {
+			delay = interval
+		}
+
+		for _, event := range events {
+			handler(event)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Events polls until ctx is cancelled and delivers every event on the returned channel, which is closed once the
+// watcher stops
+func (watcher *DiversionWatcher) Events(ctx context.Context) <-chan DiversionEvent {
+	events := make(chan DiversionEvent)
+
+	go func() {
+		defer close(events)
+
+		watcher.Watch(ctx, func(event DiversionEvent) {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	return events
+}
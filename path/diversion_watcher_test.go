@@ -0,0 +1,90 @@
+package path
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestDiversionWatcher ensures that changes between polls are emitted once, even after the watcher restarts
+func TestDiversionWatcher(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"GET /diversions": {http.StatusOK, `{"diversion":[
+			{"subnet":"203.0.113.0/24","under_attack":[{"host":"203.0.113.5","reason":"UDP flood"}]},
+			{"subnet":"198.51.100.0/24","manual":true}
+		]}`},
+	})
+	defer closeAPI()
+
+	dir, err := ioutil.TempDir("", "go-path")
+	if err != nil {
+		t.Fatalf("Error creating temporary directory: %s\n", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	store := FileDiversionStateStore{Path: filepath.Join(dir, "diversions.json")}
+	clock := fixedClock{}
+
+	watcher := DiversionWatcher{Client: client, Store: store, Clock: clock}
+	events, err := watcher.Poll()
+	if err != nil {
+		t.Fatalf("Error polling diversions: %s\n", err.Error())
+	}
+
+	expected := []DiversionEvent{
+		{Type: DiversionStarted, Subnet: "198.51.100.0/24", Manual: true},
+		{Type: DiversionStarted, Subnet: "203.0.113.0/24"},
+		{Type: HostUnderAttack, Subnet: "203.0.113.0/24", Host: "203.0.113.5", Reason: "UDP flood"},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected %+v, got %+v\n", expected, events)
+	}
+
+	api.routes["GET /diversions"] = mockResponse{http.StatusOK, `{"diversion":[{"subnet":"203.0.113.0/24"}]}`}
+
+	// A new watcher picks up where the previous one left off
+	restarted := DiversionWatcher{Client: client, Store: store, Clock: clock}
+	events, err = restarted.Poll()
+	if err != nil {
+		t.Fatalf("Error polling diversions: %s\n", err.Error())
+	}
+
+	expected = []DiversionEvent{
+		{Type: DiversionEnded, Subnet: "198.51.100.0/24", Manual: true},
+		{Type: HostRecovered, Subnet: "203.0.113.0/24", Host: "203.0.113.5", Reason: "UDP flood"},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected %+v, got %+v\n", expected, events)
+	}
+
+	events, err = restarted.Poll()
+	if err != nil || len(events) != 0 {
+		t.Errorf("Expected no events, got %+v (error: %v)\n", events, err)
+	}
+}
+
+// TestDiversionWatcherDefaultInterval ensures that a watcher without an interval waits between polls instead of
+// polling continuously
+func TestDiversionWatcherDefaultInterval(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"GET /diversions": {http.StatusOK, `{"diversion":[]}`},
+	})
+	defer closeAPI()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	watcher := DiversionWatcher{Client: client}
+	if err := watcher.Watch(ctx, func(DiversionEvent) {}); err != context.DeadlineExceeded {
+		t.Errorf("Expected %+v, got %+v\n", context.DeadlineExceeded, err)
+	}
+
+	if len(api.requests) != 1 {
+		t.Errorf("Expected a single poll, got %+v\n", api.requests)
+	}
+}
@@ -15,7 +15,7 @@ type Diversion struct {
 
 // Provides details for a diversion
 type UnderAttack struct {
-	Host   string `json:"host"`
-	Reason string `json:"reason"`
-	Since  string `json:"since"`
+	Host   string       `json:"host"`
+	Reason AttackReason `json:"reason"`
+	Since  Timestamp    `json:"since"`
 }
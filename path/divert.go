@@ -0,0 +1,132 @@
+package path
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ErrAlreadyDiverted is returned by DivertFor and DivertDuring if the prefix is already diverted. The existing diversion
+// is left in place, as withdrawing it afterwards would end a diversion which was not made by the caller
+var ErrAlreadyDiverted = errors.New("prefix is already diverted")
+
+// Defaults of the retries of withdrawals made by DivertDuring, used unless set with WithWithdrawalRetry
+const (
+	defaultWithdrawalAttempts = 5
+	defaultWithdrawalBackoff  = time.Second
+	defaultWithdrawalMaxWait  = 30 * time.Second
+)
+
+// withdrawalRetry configures how failed withdrawals are retried. Zero fields take their default
+type withdrawalRetry struct {
+	attempts int
+	backoff  time.Duration
+	maxWait  time.Duration
+}
+
+// orDefault fills in the fields which were not set
+func (retry withdrawalRetry) orDefault() withdrawalRetry {
+	if retry.attempts <= 0 {
+		retry.attempts = defaultWithdrawalAttempts
+	}
+	if retry.backoff <= 0 {
+		retry.backoff = defaultWithdrawalBackoff
+	}
+	if retry.maxWait <= 0 {
+		retry.maxWait = defaultWithdrawalMaxWait
+	}
+
+	return retry
+}
+
+// DivertFor manually diverts a prefix such as "203.0.113.0/24" for the given duration, and withdraws the diversion
+// afterwards. It blocks until the diversion has been withdrawn. If ctx is cancelled early, the diversion is withdrawn
+// immediately and the context's error is returned
+func (client *Client) DivertFor(ctx context.Context, prefix string, duration time.Duration) error {
+	now := time.Now()
+
+	return client.DivertDuring(ctx, prefix, now, now.Add(duration))
+}
+
+// DivertDuring waits until start, manually diverts a prefix such as "203.0.113.0/24", and withdraws the diversion at
+// end. This allows a prefix to be diverted ahead of an expected attack, such as a game launch. It blocks until the
+// diversion has been withdrawn. If ctx is cancelled before start, no diversion is made; if it is cancelled while the
+// prefix is diverted, the diversion is withdrawn immediately. In both cases the context's error is returned.
+//
+// A failed withdrawal is retried with exponential backoff, as configured with WithWithdrawalRetry. The retries are made
+// even if ctx was cancelled, since cancelling is a request to end the diversion early rather than to leave it in place,
+// so they are bounded by their own maximum wait instead. If every attempt fails, the prefix is left diverted and an
+// error saying so is returned, so that the caller can withdraw the diversion with DeleteDiversion
+func (client *Client) DivertDuring(ctx context.Context, prefix string, start, end time.Time) error {
+	_, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return err
+	}
+
+	if err := sleepUntil(ctx, start); err != nil {
+		return err
+	}
+
+	diversions, err := client.GetDiversions()
+	if err != nil {
+		return err
+	}
+
+	for _, diversion := range diversions.Diversions {
+		if _, diverted, err := net.ParseCIDR(diversion.Subnet); err == nil && diverted.String() == network.String() {
+			return ErrAlreadyDiverted
+		}
+	}
+
+	if _, err := client.CreateDiversion(network.String()); err != nil {
+		return err
+	}
+
+	waitErr := sleepUntil(ctx, end)
+
+	if err := client.withdraw(network); err != nil {
+		return err
+	}
+
+	return waitErr
+}
+
+// withdraw deletes the diversion of a prefix, retrying with exponential backoff when it fails. The caller's context is
+// deliberately not used, so that a cancelled diversion is still withdrawn
+func (client *Client) withdraw(network *net.IPNet) error {
+	prefixLength, _ := network.Mask.Size()
+	retry := client.withdrawalRetry.orDefault()
+
+	ctx, cancel := context.WithTimeout(context.Background(), retry.maxWait)
+	defer cancel()
+
+	delay := retry.backoff
+	attempts := 0
+	for {
+		attempts++
+		err := client.DeleteDiversion(network.IP.String(), prefixLength)
+		if err == nil {
+			return nil
+		}
+
+		if attempts >= retry.attempts || sleepUntil(ctx, time.Now().Add(delay)) != nil {
+			return fmt.Errorf("%s is still diverted, withdrawing it failed %d times: %w", network, attempts, err)
+		}
+		delay *= 2
+	}
+}
+
+// sleepUntil blocks until the given time or until ctx is cancelled, whichever happens first
+func sleepUntil(ctx context.Context, t time.Time) error {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,79 @@
+package path
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDivertFor ensures that a bounded diversion is created and withdrawn again
+func TestDivertFor(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"GET /diversions":                   {http.StatusOK, `{"diversion":[{"subnet":"198.51.100.0/24"}]}`},
+		"POST /diversions/203.0.113.0/24":   {http.StatusOK, `{"subnet":"203.0.113.0/24","manual":true}`},
+		"DELETE /diversions/203.0.113.0/24": {http.StatusOK, `{"acknowledged":true}`},
+	})
+	defer closeAPI()
+
+	if err := client.DivertFor(context.Background(), "203.0.113.0/24", 10*time.Millisecond); err != nil {
+		t.Fatalf("Error diverting prefix: %s\n", err.Error())
+	}
+
+	expectedRequests := []string{"GET /diversions", "POST /diversions/203.0.113.0/24", "DELETE /diversions/203.0.113.0/24"}
+	if !reflect.DeepEqual(api.requests, expectedRequests) {
+		t.Errorf("Expected %v, got %v\n", expectedRequests, api.requests)
+	}
+
+	if err := client.DivertFor(context.Background(), "198.51.100.0/24", time.Millisecond); err != ErrAlreadyDiverted {
+		t.Errorf("Expected %v, got %v\n", ErrAlreadyDiverted, err)
+	}
+}
+
+// TestDivertForRetriesWithdrawal ensures that a failed withdrawal is retried, and reported once every attempt failed
+func TestDivertForRetriesWithdrawal(t *testing.T) {
+	failures := 2
+	deletes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			fmt.Fprint(w, `{"diversion":[]}`)
+		case http.MethodPost:
+			fmt.Fprint(w, `{"subnet":"203.0.113.0/24","manual":true}`)
+		case http.MethodDelete:
+			deletes++
+			if deletes <= failures {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			fmt.Fprint(w, `{"acknowledged":true}`)
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{token: Token{AccessToken: "test", TokenType: "bearer"}, httpClient: server.Client(),
+		baseURL: server.URL}
+	WithWithdrawalRetry(3, time.Millisecond, time.Second)(client)
+
+	if err := client.DivertFor(context.Background(), "203.0.113.0/24", time.Millisecond); err != nil || deletes != 3 {
+		t.Errorf("Expected the third withdrawal to succeed, got %v after %d attempts\n", err, deletes)
+	}
+
+	failures, deletes = 3, 0
+	err := client.DivertFor(context.Background(), "203.0.113.0/24", time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "still diverted") || deletes != 3 {
+		t.Errorf("Expected the withdrawal to be reported as failed, got %v after %d attempts\n", err, deletes)
+	}
+
+	// The total wait is capped, whatever the number of attempts left
+	WithWithdrawalRetry(100, 20*time.Millisecond, 100*time.Millisecond)(client)
+	failures, deletes = 100, 0
+	err = client.DivertFor(context.Background(), "203.0.113.0/24", time.Millisecond)
+	if err == nil || deletes != 3 {
+		t.Errorf("Expected three attempts within the maximum wait, got %v after %d attempts\n", err, deletes)
+	}
+}
@@ -1,5 +1,10 @@
 package path
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Represents a generic error
 type Error struct {
 	Detail string `json:"detail"`
@@ -23,4 +28,34 @@ type ValidationErrorItem struct {
 	Msg  string   `json:"msg"`
 	// The type of ValidationError that occurred
 	Type string   `json:"type"`
-}
\ No newline at end of file
+}
+
+// Error lists every validation issue, one per line, so that a ValidationError can be returned as an error
+func (validationError ValidationError) Error() string {
+	var errMsg strings.Builder
+	for _, errEntry := range validationError.Detail {
+		errMsg.WriteString(
+			fmt.Sprintf("\n- Message: %s\n  Type: %s\n  Location: %s", errEntry.Msg, errEntry.Type, errEntry.Loc),
+		)
+	}
+
+	return errMsg.String()
+}
+
+// ResponseError is returned when the API responds with an unsuccessful status code, except for 422 responses, which
+// are returned as a ValidationError. Its message is the one derived from the response body, which can be reached with
+// errors.As or Unwrap
+type ResponseError struct {
+	StatusCode int
+	Err        error
+}
+
+// Error returns the message of the underlying error
+func (responseError *ResponseError) Error() string {
+	return responseError.Err.Error()
+}
+
+// Unwrap returns the error derived from the response body
+func (responseError *ResponseError) Unwrap() error {
+	return responseError.Err
+}
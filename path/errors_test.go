@@ -2,6 +2,7 @@ package path
 
 import (
 	"encoding/json"
+	"net/http"
 	"reflect"
 	"testing"
 )
@@ -74,3 +75,19 @@ func TestHTTPValidationError(t *testing.T) {
 		t.Errorf("Expected %+v, got %+v\n", expected, got)
 	}
 }
+
+// TestResponseError ensures that 422 responses are still returned as a bare ValidationError, and that other error
+// responses carry their status code
+func TestResponseError(t *testing.T) {
+	const jsonError = `{"detail":[{"loc":["body","password"],"msg":"field required","type":"value_error.missing"}]}`
+
+	if _, ok := responseError(http.StatusUnprocessableEntity, []byte(jsonError)).(ValidationError); !ok {
+		t.Errorf("Expected a ValidationError for a 422 response\n")
+	}
+
+	err := responseError(http.StatusUnauthorized, []byte(`{"detail":"Not authenticated"}`))
+	got, ok := err.(*ResponseError)
+	if !ok || got.StatusCode != http.StatusUnauthorized || got.Error() != "Not authenticated" {
+		t.Errorf("Expected %+v, got %+v\n", http.StatusUnauthorized, err)
+	}
+}
@@ -0,0 +1,452 @@
+package path
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FilterInventory compares the application filters deployed on an account with the filter types available to it. A
+// filter's Name holds its type
+type FilterInventory struct {
+	Deployed  []Filter
+	Available []Filter
+	// Unused lists the available filter types which have no filter deployed, in alphabetical order
+	Unused []string
+	// Unavailable lists the deployed filters whose type is no longer available to the account
+	Unavailable []Filter
+	// Duplicates groups the deployed filters which share their type and settings. Only groups of two or more filters are
+	// listed
+	Duplicates [][]Filter
+}
+
+// NewFilterInventory builds the inventory of the deployed filters against the available ones
+func NewFilterInventory(deployed, available Filters) FilterInventory {
+	inventory := FilterInventory{Deployed: deployed.Filters, Available: available.Filters}
+
+	deployedTypes := map[string]bool{}
+	for _, filter := range deployed.Filters {
+		deployedTypes[filter.Name] = true
+	}
+
+	availableTypes := map[string]bool{}
+	for _, filter := range available.Filters {
+		availableTypes[filter.Name] = true
+
+		if !deployedTypes[filter.Name] {
+			inventory.Unused = append(inventory.Unused, filter.Name)
+		}
+	}
+	sort.Strings(inventory.Unused)
+
+	for _, filter := range deployed.Filters {
+		if !availableTypes[filter.Name] {
+			inventory.Unavailable = append(inventory.Unavailable, filter)
+		}
+	}
+
+	inventory.Duplicates = duplicateFilters(deployed.Filters)
+
+	return inventory
+}
+
+// GetFilterInventory fetches the deployed and available filters of the account and builds their inventory
+func (client *Client) GetFilterInventory() (FilterInventory, error) {
+	deployed, err := client.GetFilters()
+	if err != nil {
+		return FilterInventory{}, err
+	}
+
+	available, err := client.GetAvailableFilters()
+	if err != nil {
+		return FilterInventory{}, err
+	}
+
+	return NewFilterInventory(deployed, available), nil
+}
+
+// duplicateFilters groups the filters which share their type and settings
+func duplicateFilters(filters []Filter) [][]Filter {
+	var groups [][]Filter
+	grouped := make([]bool, len(filters))
+
+	for i := range filters {
+		if grouped[i] {
+			continue
+		}
+
+		group := []Filter{filters[i]}
+		for j := i + 1; j < len(filters); j++ {
+			if !grouped[j] && filters[j].Name == filters[i].Name && sameSettings(filters[i].Settings, filters[j].Settings) {
+				group = append(group, filters[j])
+				grouped[j] = true
+			}
+		}
+
+		if len(group) > 1 {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups
+}
+
+// sameSettings reports whether two sets of settings hold the same values once encoded for the API
+func sameSettings(a, b interface{}) bool {
+	aValues, aErr := settingsValues(a)
+	bValues, bErr := settingsValues(b)
+
+	return aErr == nil && bErr == nil && reflect.DeepEqual(aValues, bValues)
+}
+
+// FilterSpec is an application filter as declared in a FilterSet
+type FilterSpec struct {
+	Type string `json:"type"`
+	// Settings configures the filter. Fields which are left out are not compared with the deployed filters, so that
+	// defaults filled in by the API do not cause updates
+	Settings FilterSettings `json:"settings,omitempty"`
+}
+
+// String formats the spec such as "minecraft {port=25565}"
+func (spec FilterSpec) String() string {
+	values, err := settingsValues(spec.Settings)
+	if err != nil || len(values) == 0 {
+		return spec.Type
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]string, len(names))
+	for i, name := range names {
+		fields[i] = fmt.Sprintf("%s=%v", name, values[name])
+	}
+
+	return fmt.Sprintf("%s {%s}", spec.Type, strings.Join(fields, ", "))
+}
+
+// matches reports whether a deployed filter has the spec's type and every setting the spec declares
+func (spec FilterSpec) matches(filter Filter) bool {
+	if filter.Name != spec.Type {
+		return false
+	}
+
+	declared, err := settingsValues(spec.Settings)
+	if err != nil {
+		return false
+	}
+
+	deployed, err := settingsValues(filter.Settings)
+	if err != nil {
+		return false
+	}
+
+	for name, value := range declared {
+		if !reflect.DeepEqual(deployed[name], value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FilterSet declares the application filters an account should have. Filters of the types in the set are created,
+// updated and deleted to match it, while filters of other types are left alone unless Prune is set
+type FilterSet struct {
+	Filters []FilterSpec `json:"filters"`
+	// Prune deletes the deployed filters whose type does not appear in the set
+	Prune bool `json:"prune,omitempty"`
+}
+
+// FilterActionType identifies the change made to a filter when a plan is applied
+type FilterActionType string
+
+// Changes made to filters when a plan is applied
+const (
+	FilterCreate FilterActionType = "create"
+	FilterUpdate FilterActionType = "update"
+	FilterDelete FilterActionType = "delete"
+)
+
+// FilterAction is a single change of a FilterPlan
+type FilterAction struct {
+	Type FilterActionType
+	// Filter is the deployed filter to update or delete
+	Filter Filter
+	// Spec is the declared filter to create, or the settings to update the filter with
+	Spec FilterSpec
+}
+
+// String formats the action such as "update minecraft f1 to minecraft {port=25566}"
+func (action FilterAction) String() string {
+	switch action.Type {
+	case FilterCreate:
+		return fmt.Sprintf("create %s", action.Spec)
+	case FilterUpdate:
+		return fmt.Sprintf("update %s %s to %s", action.Filter.Name, action.Filter.ID, action.Spec)
+	default:
+		return fmt.Sprintf("%s %s %s", action.Type, action.Filter.Name, action.Filter.ID)
+	}
+}
+
+// FilterPlan lists the changes leading from the deployed filters to a FilterSet
+type FilterPlan struct {
+	Actions []FilterAction
+	// Unchanged lists the deployed filters which already match the set
+	Unchanged []Filter
+}
+
+// PlanFilters computes the changes leading from the deployed filters to the set. Every spec is first paired with a
+// deployed filter matching it, then the remaining specs of a type with the remaining filters of that type, which are
+// updated. Specs left over are created and filters left over are deleted, so duplicates of a filter are removed.
+//
+// If available lists any filter types, specs of other types are an error. Specs which declare the same type and
+// settings twice are an error as well
+func PlanFilters(set FilterSet, deployed, available Filters) (FilterPlan, error) {
+	if duplicates := duplicateSpecs(set.Filters); len(duplicates) > 0 {
+		return FilterPlan{}, fmt.Errorf("filter %s is declared more than once", duplicates[0])
+	}
+
+	if len(available.Filters) > 0 {
+		availableTypes := map[string]bool{}
+		for _, filter := range available.Filters {
+			availableTypes[filter.Name] = true
+		}
+
+		for _, spec := range set.Filters {
+			if !availableTypes[spec.Type] {
+				return FilterPlan{}, fmt.Errorf("filter type %q is not available", spec.Type)
+			}
+		}
+	}
+
+	var plan FilterPlan
+	for _, pair := range pairFilters(set.Filters, deployed.Filters, set.Prune, FilterSpec.matches) {
+		switch {
+		case pair.matched:
+			plan.Unchanged = append(plan.Unchanged, deployed.Filters[pair.filter])
+		case pair.filter < 0:
+			plan.Actions = append(plan.Actions, FilterAction{Type: FilterCreate, Spec: set.Filters[pair.spec]})
+		case pair.spec < 0:
+			plan.Actions = append(plan.Actions, FilterAction{Type: FilterDelete, Filter: deployed.Filters[pair.filter]})
+		default:
+			plan.Actions = append(plan.Actions, FilterAction{
+				Type: FilterUpdate, Filter: deployed.Filters[pair.filter], Spec: set.Filters[pair.spec],
+			})
+		}
+	}
+
+	return plan, nil
+}
+
+// filterPair pairs a spec with a deployed filter by their index. Either index is -1 if it has no counterpart
+type filterPair struct {
+	spec    int
+	filter  int
+	matched bool
+}
+
+// pairFilters pairs specs with deployed filters of the same type, by type in alphabetical order. Every spec is first
+// paired with the first remaining filter it matches, then the remaining specs and filters are paired in order. Filters
+// of types without any spec are only listed if prune is set
+func pairFilters(specs []FilterSpec, filters []Filter, prune bool, match func(FilterSpec, Filter) bool) []filterPair {
+	specsByType := map[string][]int{}
+	for i, spec := range specs {
+		specsByType[spec.Type] = append(specsByType[spec.Type], i)
+	}
+
+	filtersByType := map[string][]int{}
+	for i, filter := range filters {
+		filtersByType[filter.Name] = append(filtersByType[filter.Name], i)
+	}
+
+	types := make([]string, 0, len(specsByType)+len(filtersByType))
+	for filterType := range specsByType {
+		types = append(types, filterType)
+	}
+	for filterType := range filtersByType {
+		if _, ok := specsByType[filterType]; !ok && prune {
+			types = append(types, filterType)
+		}
+	}
+	sort.Strings(types)
+
+	var pairs []filterPair
+	for _, filterType := range types {
+		var unmatchedSpecs []int
+		matched := map[int]bool{}
+		for _, spec := range specsByType[filterType] {
+			found := false
+			for _, filter := range filtersByType[filterType] {
+				if !matched[filter] && match(specs[spec], filters[filter]) {
+					matched[filter], found = true, true
+					pairs = append(pairs, filterPair{spec: spec, filter: filter, matched: true})
+					break
+				}
+			}
+
+			if !found {
+				unmatchedSpecs = append(unmatchedSpecs, spec)
+			}
+		}
+
+		var unmatchedFilters []int
+		for _, filter := range filtersByType[filterType] {
+			if !matched[filter] {
+				unmatchedFilters = append(unmatchedFilters, filter)
+			}
+		}
+
+		for i := 0; i < len(unmatchedSpecs) || i < len(unmatchedFilters); i++ {
+			pair := filterPair{spec: -1, filter: -1}
+			if i < len(unmatchedSpecs) {
+				pair.spec = unmatchedSpecs[i]
+			}
+			if i < len(unmatchedFilters) {
+				pair.filter = unmatchedFilters[i]
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+
+	return pairs
+}
+
+// duplicateSpecs returns the specs which are declared more than once, once each
+func duplicateSpecs(specs []FilterSpec) []FilterSpec {
+	var duplicates []FilterSpec
+	for i := range specs {
+		for j := 0; j < i; j++ {
+			if specs[i].Type == specs[j].Type && sameSettings(specs[i].Settings, specs[j].Settings) {
+				duplicates = append(duplicates, specs[i])
+				break
+			}
+		}
+	}
+
+	return duplicates
+}
+
+// PlanFilterSet fetches the deployed and available filters of the account and plans the changes leading to the set
+func (client *Client) PlanFilterSet(set FilterSet) (FilterPlan, error) {
+	inventory, err := client.GetFilterInventory()
+	if err != nil {
+		return FilterPlan{}, err
+	}
+
+	return PlanFilters(set, Filters{Filters: inventory.Deployed}, Filters{Filters: inventory.Available})
+}
+
+// FilterApplyReport describes the outcome of applying a FilterPlan
+type FilterApplyReport struct {
+	Created []Filter
+	Updated []Filter
+	Deleted []Filter
+	// Actions which could not be applied
+	Failed []FilterFailure
+}
+
+// FilterFailure holds an action which could not be applied along with the reason why
+type FilterFailure struct {
+	Action FilterAction
+	Err    error
+}
+
+// ApplyFilterPlan makes the changes of the plan. Filters are created and updated before any is deleted, so that the
+// account is not left without protection in between. Actions which fail are reported and do not stop the others
+func (client *Client) ApplyFilterPlan(plan FilterPlan) FilterApplyReport {
+	var report FilterApplyReport
+
+	for _, actionType := range []FilterActionType{FilterCreate, FilterUpdate, FilterDelete} {
+		for _, action := range plan.Actions {
+			if action.Type != actionType {
+				continue
+			}
+
+			settings := action.Spec.Settings
+			if settings == nil {
+				settings = FilterSettings{}
+			}
+
+			switch action.Type {
+			case FilterCreate:
+				created, err := client.CreateFilter(action.Spec.Type, settings)
+				if err != nil {
+					report.Failed = append(report.Failed, FilterFailure{Action: action, Err: err})
+					continue
+				}
+				report.Created = append(report.Created, created)
+
+			case FilterUpdate:
+				updated, err := client.UpdateFilter(action.Filter.Name, action.Filter.ID, settings)
+				if err != nil {
+					report.Failed = append(report.Failed, FilterFailure{Action: action, Err: err})
+					continue
+				}
+				report.Updated = append(report.Updated, updated)
+
+			case FilterDelete:
+				if err := client.DeleteFilter(action.Filter.Name, action.Filter.ID); err != nil {
+					report.Failed = append(report.Failed, FilterFailure{Action: action, Err: err})
+					continue
+				}
+				report.Deleted = append(report.Deleted, action.Filter)
+			}
+		}
+	}
+
+	return report
+}
+
+// FilterDiff describes how the filters of two accounts differ
+type FilterDiff struct {
+	// OnlyLeft and OnlyRight list the filters deployed on one account with no counterpart of the same type on the
+	// other
+	OnlyLeft  []Filter
+	OnlyRight []Filter
+	// Changed pairs filters of the same type whose settings differ
+	Changed []FilterChange
+}
+
+// FilterChange pairs filters of the same type deployed on two accounts with different settings
+type FilterChange struct {
+	Left  Filter
+	Right Filter
+}
+
+// Empty reports whether both accounts have the same filters
+func (diff FilterDiff) Empty() bool {
+	return len(diff.OnlyLeft) == 0 && len(diff.OnlyRight) == 0 && len(diff.Changed) == 0
+}
+
+// DiffFilters compares the filters deployed on two accounts. IDs differ between accounts, so filters are paired by
+// type and settings in the same way as PlanFilters pairs specs with deployed filters
+func DiffFilters(left, right Filters) FilterDiff {
+	specs := make([]FilterSpec, len(right.Filters))
+	for i, filter := range right.Filters {
+		specs[i] = FilterSpec{Type: filter.Name, Settings: filter.Settings}
+	}
+
+	sameFilter := func(spec FilterSpec, filter Filter) bool {
+		return sameSettings(spec.Settings, filter.Settings)
+	}
+
+	var diff FilterDiff
+	for _, pair := range pairFilters(specs, left.Filters, true, sameFilter) {
+		switch {
+		case pair.matched:
+		case pair.filter < 0:
+			diff.OnlyRight = append(diff.OnlyRight, right.Filters[pair.spec])
+		case pair.spec < 0:
+			diff.OnlyLeft = append(diff.OnlyLeft, left.Filters[pair.filter])
+		default:
+			diff.Changed = append(diff.Changed, FilterChange{Left: left.Filters[pair.filter], Right: right.Filters[pair.spec]})
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,139 @@
+package path
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// TestFilterInventory ensures that unused, unavailable and duplicate filters are found
+func TestFilterInventory(t *testing.T) {
+	deployed := Filters{Filters: []Filter{
+		{ID: "1", Name: "minecraft", Settings: FilterSettings{"port": 25565.0}},
+		{ID: "2", Name: "legacy"},
+		{ID: "3", Name: "minecraft", Settings: FilterSettings{"port": 25565.0}},
+		{ID: "4", Name: "minecraft", Settings: FilterSettings{"port": 25566.0}},
+	}}
+	available := Filters{Filters: []Filter{{Name: "teamspeak"}, {Name: "minecraft"}, {Name: "source"}}}
+
+	inventory := NewFilterInventory(deployed, available)
+
+	if expected := []string{"source", "teamspeak"}; !reflect.DeepEqual(inventory.Unused, expected) {
+		t.Errorf("Expected %+v, got %+v\n", expected, inventory.Unused)
+	}
+
+	if len(inventory.Unavailable) != 1 || inventory.Unavailable[0].ID != "2" {
+		t.Errorf("Unexpected unavailable filters: %+v\n", inventory.Unavailable)
+	}
+
+	if len(inventory.Duplicates) != 1 || len(inventory.Duplicates[0]) != 2 || inventory.Duplicates[0][1].ID != "3" {
+		t.Errorf("Unexpected duplicate filters: %+v\n", inventory.Duplicates)
+	}
+}
+
+// TestPlanFilters ensures that matching filters are kept, mismatching ones updated, duplicates deleted and missing ones
+// created, while filters of other types are only deleted when pruning
+func TestPlanFilters(t *testing.T) {
+	deployed := Filters{Filters: []Filter{
+		{ID: "1", Name: "minecraft", Settings: FilterSettings{"port": 25565.0, "default": true}},
+		{ID: "2", Name: "minecraft", Settings: FilterSettings{"port": 25565.0}},
+		{ID: "3", Name: "source", Settings: FilterSettings{"port": 27015.0}},
+		{ID: "4", Name: "legacy"},
+	}}
+	set := FilterSet{Filters: []FilterSpec{
+		{Type: "minecraft", Settings: FilterSettings{"port": 25565}},
+		{Type: "source", Settings: FilterSettings{"port": 27016}},
+		{Type: "teamspeak"},
+	}}
+
+	plan, err := PlanFilters(set, deployed, Filters{})
+	if err != nil {
+		t.Fatalf("Error planning filters: %s\n", err.Error())
+	}
+
+	var got []string
+	for _, action := range plan.Actions {
+		got = append(got, action.String())
+	}
+
+	expected := []string{"delete minecraft 2", "update source 3 to source {port=27016}", "create teamspeak"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v\n", expected, got)
+	}
+
+	if len(plan.Unchanged) != 1 || plan.Unchanged[0].ID != "1" {
+		t.Errorf("Unexpected unchanged filters: %+v\n", plan.Unchanged)
+	}
+
+	set.Prune = true
+	plan, _ = PlanFilters(set, deployed, Filters{})
+	if len(plan.Actions) != 4 || plan.Actions[0].String() != "delete legacy 4" {
+		t.Errorf("Expected the legacy filter to be pruned, got %+v\n", plan.Actions)
+	}
+
+	set.Filters = append(set.Filters, FilterSpec{Type: "teamspeak"})
+	if _, err := PlanFilters(set, deployed, Filters{}); err == nil {
+		t.Errorf("Expected an error for a filter declared twice\n")
+	}
+}
+
+// TestApplyFilterPlan ensures that filters are created and updated before any is deleted
+func TestApplyFilterPlan(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"DELETE /filters/minecraft/2": {http.StatusOK, `{"acknowledged": true}`},
+		"POST /filters/source/3":      {http.StatusOK, `{"id": "3", "name": "source", "port": 27016}`},
+		"POST /filters/teamspeak":     {http.StatusOK, `{"id": "5", "name": "teamspeak"}`},
+	})
+	defer closeAPI()
+
+	report := client.ApplyFilterPlan(FilterPlan{Actions: []FilterAction{
+		{Type: FilterDelete, Filter: Filter{ID: "2", Name: "minecraft"}},
+		{
+			Type:   FilterUpdate,
+			Filter: Filter{ID: "3", Name: "source"},
+			Spec:   FilterSpec{Type: "source", Settings: FilterSettings{"port": 27016}},
+		},
+		{Type: FilterCreate, Spec: FilterSpec{Type: "teamspeak"}},
+	}})
+
+	expected := []string{"POST /filters/teamspeak", "POST /filters/source/3", "DELETE /filters/minecraft/2"}
+	if !reflect.DeepEqual(api.requests, expected) {
+		t.Errorf("Expected %+v, got %+v\n", expected, api.requests)
+	}
+
+	if len(report.Created) != 1 || len(report.Updated) != 1 || len(report.Deleted) != 1 || len(report.Failed) != 0 {
+		t.Errorf("Unexpected report: %+v\n", report)
+	}
+}
+
+// TestDiffFilters ensures that filters are paired across accounts by type and settings
+func TestDiffFilters(t *testing.T) {
+	left := Filters{Filters: []Filter{
+		{ID: "a1", Name: "minecraft", Settings: FilterSettings{"port": 25565.0}},
+		{ID: "a2", Name: "source", Settings: FilterSettings{"port": 27015.0}},
+		{ID: "a3", Name: "legacy"},
+	}}
+	right := Filters{Filters: []Filter{
+		{ID: "b1", Name: "source", Settings: FilterSettings{"port": 27016.0}},
+		{ID: "b2", Name: "minecraft", Settings: FilterSettings{"port": 25565.0}},
+		{ID: "b3", Name: "teamspeak"},
+	}}
+
+	diff := DiffFilters(left, right)
+
+	if len(diff.OnlyLeft) != 1 || diff.OnlyLeft[0].ID != "a3" {
+		t.Errorf("Unexpected filters on the left account only: %+v\n", diff.OnlyLeft)
+	}
+
+	if len(diff.OnlyRight) != 1 || diff.OnlyRight[0].ID != "b3" {
+		t.Errorf("Unexpected filters on the right account only: %+v\n", diff.OnlyRight)
+	}
+
+	if len(diff.Changed) != 1 || diff.Changed[0].Left.ID != "a2" || diff.Changed[0].Right.ID != "b1" {
+		t.Errorf("Unexpected changed filters: %+v\n", diff.Changed)
+	}
+
+	if !DiffFilters(left, left).Empty() {
+		t.Errorf("Expected no differences between an account and itself\n")
+	}
+}
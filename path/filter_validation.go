@@ -0,0 +1,252 @@
+package path
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Option returns the schema of the filter type with the given name
+func (options FiltersOptions) Option(filterType string) (FilterOption, bool) {
+	for _, option := range options.Filters {
+		if option.Name == filterType {
+			return option, true
+		}
+	}
+
+	return FilterOption{}, false
+}
+
+// Field returns the field of the filter with the given name
+func (option FilterOption) Field(name string) (FilterOptionField, bool) {
+	for _, field := range option.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+
+	return FilterOptionField{}, false
+}
+
+// ValidateFilter checks the settings of a filter of the given type against its schema, as FilterOption.Validate does.
+// An unknown filter type is reported as a validation issue
+func (options FiltersOptions) ValidateFilter(filterType string, settings interface{}) error {
+	option, ok := options.Option(filterType)
+	if !ok {
+		return ValidationError{Detail: []ValidationErrorItem{{
+			Loc:  []string{"path", "filter_type"},
+			Msg:  fmt.Sprintf("unknown filter type %q", filterType),
+			Type: "value_error.filter_type",
+		}}}
+	}
+
+	return option.Validate(settings)
+}
+
+// Validate checks settings against the filter's schema before they are sent with CreateFilter or UpdateFilter. The
+// settings are given as for CreateFilter. Every issue found is returned in a ValidationError, located and worded like
+// the issues reported by the API, or nil if the settings are valid.
+//
+// Each integer bound is checked whenever it is set, including a bound of zero, and maximum lengths only if they are
+// positive. Fields whose type is not known are not checked
+func (option FilterOption) Validate(settings interface{}) error {
+	values, err := settingsValues(settings)
+	if err != nil {
+		return err
+	}
+
+	var issues []ValidationErrorItem
+	for _, field := range option.Fields {
+		value, ok := values[field.Name]
+		if !ok || value == nil {
+			if field.Required {
+				issues = append(issues, ValidationErrorItem{
+					Loc: []string{"body", field.Name}, Msg: "field required", Type: "value_error.missing",
+				})
+			}
+			continue
+		}
+
+		issues = append(issues, validateFieldValue(field.Value, value, []string{"body", field.Name})...)
+	}
+
+	var extra []string
+	for name := range values {
+		if _, ok := option.Field(name); !ok {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+
+	for _, name := range extra {
+		issues = append(issues, ValidationErrorItem{
+			Loc: []string{"body", name}, Msg: "extra fields not permitted", Type: "value_error.extra",
+		})
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+
+	return ValidationError{Detail: issues}
+}
+
+// settingsValues converts settings into the field values that would be sent to the API
+func settingsValues(settings interface{}) (map[string]interface{}, error) {
+	if settings == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("filter settings must be a JSON object: %v", err)
+	}
+
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+
+	return values, nil
+}
+
+// validateFieldValue checks a single value against its description. loc locates the value within the settings
+func validateFieldValue(description FilterFieldValue, value interface{}, loc []string) []ValidationErrorItem {
+	issue := func(msg, issueType string) []ValidationErrorItem {
+		return []ValidationErrorItem{{Loc: loc, Msg: msg, Type: issueType}}
+	}
+
+	switch field := description.(type) {
+	case FilterOptionFieldBool:
+		if _, ok := value.(bool); !ok {
+			return issue("value could not be parsed to a boolean", "type_error.bool")
+		}
+
+	case FilterOptionFieldInteger:
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			return issue("value is not a valid integer", "type_error.integer")
+		}
+
+		if field.Min != nil && number < float64(*field.Min) {
+			return issue(fmt.Sprintf("ensure this value is greater than or equal to %d", *field.Min),
+				"value_error.number.not_ge")
+		}
+		if field.Max != nil && number > float64(*field.Max) {
+			return issue(fmt.Sprintf("ensure this value is less than or equal to %d", *field.Max),
+				"value_error.number.not_le")
+		}
+
+	case FilterOptionFieldString:
+		text, ok := value.(string)
+		if !ok {
+			return issue("str type expected", "type_error.str")
+		}
+
+		length := utf8.RuneCountInString(text)
+		if length < field.MinLength {
+			return issue(fmt.Sprintf("ensure this value has at least %d characters", field.MinLength),
+				"value_error.any_str.min_length")
+		}
+		if field.MaxLength > 0 && length > field.MaxLength {
+			return issue(fmt.Sprintf("ensure this value has at most %d characters", field.MaxLength),
+				"value_error.any_str.max_length")
+		}
+
+	case FilterOptionFieldIP:
+		if text, ok := value.(string); !ok || net.ParseIP(text) == nil {
+			return issue("value is not a valid IPv4 or IPv6 address", "value_error.ipvanyaddress")
+		}
+
+	case FilterOptionFieldCIDR:
+		if text, ok := value.(string); !ok {
+			return issue("value is not a valid IPv4 or IPv6 network", "value_error.ipvanynetwork")
+		} else if _, _, err := net.ParseCIDR(text); err != nil {
+			return issue("value is not a valid IPv4 or IPv6 network", "value_error.ipvanynetwork")
+		}
+
+	case FilterOptionFieldPortRange:
+		if !validPortRange(value) {
+			return issue("value is not a valid port or port range, such as 80 or 27015-27030", "value_error.port_range")
+		}
+
+	case FilterOptionFieldSelect:
+		text, _ := value.(string)
+
+		permitted := make([]string, len(field.Options))
+		for i, selectOption := range field.Options {
+			if text == selectOption.Value {
+				return nil
+			}
+			permitted[i] = fmt.Sprintf("'%s'", selectOption.Value)
+		}
+
+		return issue(fmt.Sprintf("value is not a valid enumeration member; permitted: %s", strings.Join(permitted, ", ")),
+			"type_error.enum")
+
+	case FilterOptionFieldArray:
+		elements, ok := value.([]interface{})
+		if !ok {
+			return issue("value is not a valid list", "type_error.list")
+		}
+
+		if len(elements) < field.MinLength {
+			return issue(fmt.Sprintf("ensure this value has at least %d items", field.MinLength),
+				"value_error.list.min_items")
+		}
+		if field.MaxLength > 0 && len(elements) > field.MaxLength {
+			return issue(fmt.Sprintf("ensure this value has at most %d items", field.MaxLength),
+				"value_error.list.max_items")
+		}
+
+		var issues []ValidationErrorItem
+		element := field.Element()
+		for i, elementValue := range elements {
+			elementLoc := append(append([]string(nil), loc...), strconv.Itoa(i))
+			issues = append(issues, validateFieldValue(element, elementValue, elementLoc)...)
+		}
+
+		return issues
+	}
+
+	return nil
+}
+
+// validPortRange reports whether a value is a port, either as a number or a string, or a range of ports such as
+// "27015-27030"
+func validPortRange(value interface{}) bool {
+	validPort := func(text string) (int, bool) {
+		port, err := strconv.Atoi(strings.TrimSpace(text))
+		return port, err == nil && port >= 1 && port <= 65535
+	}
+
+	switch portRange := value.(type) {
+	case float64:
+		return portRange == math.Trunc(portRange) && portRange >= 1 && portRange <= 65535
+	case string:
+		bounds := strings.Split(portRange, "-")
+		if len(bounds) == 1 {
+			_, ok := validPort(bounds[0])
+			return ok
+		}
+
+		if len(bounds) != 2 {
+			return false
+		}
+
+		low, lowOK := validPort(bounds[0])
+		high, highOK := validPort(bounds[1])
+		return lowOK && highOK && low <= high
+	default:
+		return false
+	}
+}
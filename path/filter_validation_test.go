@@ -0,0 +1,140 @@
+package path
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// testFilterOption is the schema of a filter using every known field type
+var testFilterOption = FilterOption{
+	Name: "game",
+	Fields: []FilterOptionField{
+		{Name: "addr", Value: FilterOptionFieldCIDR{}, Required: true},
+		{Name: "host", Value: FilterOptionFieldIP{}},
+		{Name: "port", Value: FilterOptionFieldPortRange{}, Required: true},
+		{Name: "limit", Value: FilterOptionFieldInteger{Min: intPointer(1), Max: intPointer(100)}},
+		{Name: "name", Value: FilterOptionFieldString{MinLength: 1, MaxLength: 8}},
+		{Name: "strict", Value: FilterOptionFieldBool{}},
+		{Name: "mode", Value: FilterOptionFieldSelect{Options: []FilterOptionFieldSelectOption{{Value: "a"}, {Value: "b"}}}},
+		{Name: "allow", Value: FilterOptionFieldArray{Subtype: FilterFieldIP, MaxLength: 2}},
+	},
+}
+
+// TestValidateFilterSettings ensures that valid settings pass, whether given as a map or a struct
+func TestValidateFilterSettings(t *testing.T) {
+	settings := FilterSettings{
+		"addr": "203.0.113.0/24", "host": "2001:db8::1", "port": "27015-27030", "limit": 100, "name": "srv",
+		"strict": true, "mode": "b", "allow": []string{"198.51.100.1"},
+	}
+	if err := testFilterOption.Validate(settings); err != nil {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+
+	typed := struct {
+		Addr string `json:"addr"`
+		Port int    `json:"port"`
+	}{"203.0.113.5/32", 25565}
+	if err := testFilterOption.Validate(typed); err != nil {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
+
+// TestValidateFilterSettingsIssues ensures that every issue is reported with its location and type
+func TestValidateFilterSettingsIssues(t *testing.T) {
+	settings := FilterSettings{
+		"host": "example.com", "port": "30-20", "limit": 1.5, "name": "too long a name", "strict": "yes",
+		"mode": "c", "allow": []interface{}{"198.51.100.1", "nope"}, "extra": 1,
+	}
+
+	err := testFilterOption.Validate(settings)
+
+	var validationError ValidationError
+	if !errors.As(err, &validationError) {
+		t.Fatalf("Expected a ValidationError, got %v\n", err)
+	}
+
+	var got []string
+	for _, item := range validationError.Detail {
+		got = append(got, item.Type+" "+item.Loc[len(item.Loc)-1])
+	}
+
+	expected := []string{
+		"value_error.missing addr",
+		"value_error.ipvanyaddress host",
+		"value_error.port_range port",
+		"type_error.integer limit",
+		"value_error.any_str.max_length name",
+		"type_error.bool strict",
+		"type_error.enum mode",
+		"value_error.ipvanyaddress 1",
+		"value_error.extra extra",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v\n", expected, got)
+	}
+}
+
+// TestValidateFilterType ensures that an unknown filter type is reported
+func TestValidateFilterType(t *testing.T) {
+	options := FiltersOptions{Filters: []FilterOption{testFilterOption}}
+
+	if err := options.ValidateFilter("minecraft", nil); err == nil {
+		t.Errorf("Expected an error for an unknown filter type\n")
+	}
+
+	if err := options.ValidateFilter("game", FilterSettings{"addr": "203.0.113.0/24", "port": 80}); err != nil {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
+
+// intPointer returns a pointer to an integer bound
+func intPointer(n int) *int {
+	return &n
+}
+
+// TestValidateIntegerMinOnly ensures that an integer with only a lower bound accepts any value above it
+func TestValidateIntegerMinOnly(t *testing.T) {
+	field := FilterOptionFieldInteger{Min: intPointer(10)}
+
+	if issues := validateFieldValue(field, float64(5000), []string{"limit"}); len(issues) != 0 {
+		t.Errorf("Unexpected issues: %+v\n", issues)
+	}
+
+	issues := validateFieldValue(field, float64(9), []string{"limit"})
+	if len(issues) != 1 || issues[0].Type != "value_error.number.not_ge" {
+		t.Errorf("Expected %+v, got %+v\n", "value_error.number.not_ge", issues)
+	}
+}
+
+// TestValidateIntegerMaxOnly ensures that an integer with only an upper bound accepts any value below it
+func TestValidateIntegerMaxOnly(t *testing.T) {
+	field := FilterOptionFieldInteger{Max: intPointer(100)}
+
+	if issues := validateFieldValue(field, float64(-5), []string{"limit"}); len(issues) != 0 {
+		t.Errorf("Unexpected issues: %+v\n", issues)
+	}
+
+	issues := validateFieldValue(field, float64(101), []string{"limit"})
+	if len(issues) != 1 || issues[0].Type != "value_error.number.not_le" {
+		t.Errorf("Expected %+v, got %+v\n", "value_error.number.not_le", issues)
+	}
+}
+
+// TestValidateIntegerZeroMin ensures that a lower bound of zero is checked like any other bound
+func TestValidateIntegerZeroMin(t *testing.T) {
+	var field FilterOptionFieldInteger
+	if err := json.Unmarshal([]byte(`{"type": "integer", "min": 0, "max": 100}`), &field); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	if issues := validateFieldValue(field, float64(0), []string{"limit"}); len(issues) != 0 {
+		t.Errorf("Unexpected issues: %+v\n", issues)
+	}
+
+	issues := validateFieldValue(field, float64(-5), []string{"limit"})
+	if len(issues) != 1 || issues[0].Type != "value_error.number.not_ge" {
+		t.Errorf("Expected %+v, got %+v\n", "value_error.number.not_ge", issues)
+	}
+}
@@ -0,0 +1,501 @@
+// Package filtergen generates typed Go settings for the application filters of Path. It reads the schema of the
+// filters, as returned by GetFilterOptions, and emits a Go file with a settings struct per filter type. Each struct
+// documents its fields from their descriptions, validates itself against the schema it was generated from, and can
+// create or update the filter through a client. The path-filtergen command wraps this package for use with go generate:
+//
+//	//go:generate go run github.com/path-network/go-path/cmd/path-filtergen -input filters.json -output filters.go
+package filtergen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/path-network/go-path"
+)
+
+// Config configures the generated file
+type Config struct {
+	// Package is the name of the package of the generated file
+	Package string
+	// Command is recorded in the header of the generated file. If empty, "path-filtergen" is used
+	Command string
+}
+
+// initialisms are written in upper case within identifiers
+var initialisms = map[string]bool{
+	"API": true, "BPS": true, "CIDR": true, "DNS": true, "HTTP": true, "ICMP": true, "ID": true, "IP": true,
+	"PPS": true, "TCP": true, "TTL": true, "UDP": true, "URL": true,
+}
+
+// identifier converts a name such as "max_pps" or "source-ip" into an exported Go identifier such as "MaxPPS" or
+// "SourceIP"
+func identifier(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var result strings.Builder
+	for _, word := range words {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			result.WriteString(upper)
+			continue
+		}
+
+		// Plurals of initialisms keep a lower case "s", e.g. "ips" becomes "IPs"
+		if stem := strings.ToUpper(strings.TrimSuffix(word, "s")); len(stem) < len(word) && initialisms[stem] {
+			result.WriteString(stem + "s")
+			continue
+		}
+
+		runes := []rune(word)
+		result.WriteRune(unicode.ToUpper(runes[0]))
+		result.WriteString(string(runes[1:]))
+	}
+
+	if result.Len() == 0 || unicode.IsDigit([]rune(result.String())[0]) {
+		return "X" + result.String()
+	}
+
+	return result.String()
+}
+
+// methods are declared on every settings struct, so fields cannot take their names
+var methods = map[string]bool{"FilterType": true, "Validate": true, "Create": true, "Update": true}
+
+// fieldIdentifier converts the name of a field into an identifier which does not clash with the methods of the struct
+// nor with the fields already in use. Names which differ only by punctuation, such as "dst-port" and "dst_port", are
+// told apart by a number, e.g. DstPort and DstPort2
+func fieldIdentifier(name string, used map[string]bool) string {
+	exported := identifier(name)
+	if methods[exported] {
+		exported += "Field"
+	}
+
+	unique := exported
+	for n := 2; used[unique]; n++ {
+		unique = fmt.Sprintf("%s%d", exported, n)
+	}
+	used[unique] = true
+
+	return unique
+}
+
+// lowerIdentifier converts a name into an unexported Go identifier
+func lowerIdentifier(name string) string {
+	exported := identifier(name)
+
+	// Lower the leading initialism or letter, e.g. "IPList" becomes "ipList"
+	runes := []rune(exported)
+	for i := 0; i < len(runes); i++ {
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+
+	name = string(runes)
+	if keywords[name] {
+		return name + "Value"
+	}
+
+	return name
+}
+
+// keywords, along with the names used within the generated methods, cannot be used as parameter names
+var keywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true, "default": true, "defer": true,
+	"else": true, "fallthrough": true, "for": true, "func": true, "go": true, "goto": true, "if": true,
+	"import": true, "interface": true, "map": true, "package": true, "range": true, "return": true, "select": true,
+	"struct": true, "switch": true, "type": true, "var": true, "client": true, "settings": true,
+}
+
+// goType returns the Go type holding values of a field, declaring select types as needed
+func goType(value path.FilterFieldValue, selectType string) string {
+	switch field := value.(type) {
+	case path.FilterOptionFieldBool:
+		return "bool"
+	case path.FilterOptionFieldInteger:
+		return "int"
+	case path.FilterOptionFieldSelect:
+		return selectType
+	case path.FilterOptionFieldArray:
+		return "[]" + goType(field.Element(), selectType)
+	case path.FilterOptionFieldString, path.FilterOptionFieldIP, path.FilterOptionFieldCIDR,
+		path.FilterOptionFieldPortRange:
+		return "string"
+	default:
+		return "json.RawMessage"
+	}
+}
+
+// constraint describes the values accepted by a field for its doc comment
+func constraint(value path.FilterFieldValue) string {
+	switch field := value.(type) {
+	case path.FilterOptionFieldInteger:
+		switch {
+		case field.Min != nil && field.Max != nil:
+			return fmt.Sprintf("An integer within [%d, %d]", *field.Min, *field.Max)
+		case field.Min != nil:
+			return fmt.Sprintf("An integer of at least %d", *field.Min)
+		case field.Max != nil:
+			return fmt.Sprintf("An integer of at most %d", *field.Max)
+		}
+		return "An integer"
+	case path.FilterOptionFieldString:
+		if field.MaxLength > 0 {
+			return fmt.Sprintf("Between %d and %d characters", field.MinLength, field.MaxLength)
+		}
+		if field.MinLength > 0 {
+			return fmt.Sprintf("At least %d characters", field.MinLength)
+		}
+	case path.FilterOptionFieldIP:
+		return "An IP address"
+	case path.FilterOptionFieldCIDR:
+		return "A network prefix such as 203.0.113.0/24"
+	case path.FilterOptionFieldPortRange:
+		return "A port or a range of ports such as 27015-27030"
+	case path.FilterOptionFieldArray:
+		element := lowerFirst(constraint(field.Element()))
+		if element == "" {
+			element = "values"
+		}
+		if field.MaxLength > 0 {
+			return fmt.Sprintf("Between %d and %d items, each %s", field.MinLength, field.MaxLength, element)
+		}
+		return fmt.Sprintf("A list, each item %s", element)
+	}
+
+	return ""
+}
+
+// sentences joins sentences into a single paragraph. Empty sentences are left out, and the last sentence has no
+// trailing period, like the other doc comments of this module
+func sentences(parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part = strings.TrimSuffix(strings.TrimSpace(part), "."); part != "" {
+			kept = append(kept, part)
+		}
+	}
+
+	return strings.Join(kept, ". ")
+}
+
+// comment turns text into lines of a doc comment, wrapped at about 120 columns including the indentation
+func comment(text, indent string) string {
+	var lines []string
+	line := indent + "//"
+	for _, word := range strings.Fields(text) {
+		if len(line)+1+len(word) > 116 && line != indent+"//" {
+			lines = append(lines, line)
+			line = indent + "//"
+		}
+		line += " " + word
+	}
+
+	return strings.Join(append(lines, line), "\n")
+}
+
+// generatedFilter holds the declarations generated for a single filter type
+type generatedFilter struct {
+	Type        string
+	Struct      string
+	Doc         string
+	SchemaVar   string
+	Schema      string
+	Fields      []generatedField
+	Required    []generatedField
+	SelectTypes []generatedSelect
+}
+
+// generatedField holds the declaration of a single field of a settings struct
+type generatedField struct {
+	Name      string
+	Param     string
+	Doc       string
+	Type      string
+	JSONName  string
+	Required  bool
+	OmitEmpty bool
+}
+
+// generatedSelect is a string type with a constant per option of a select field
+type generatedSelect struct {
+	Name      string
+	Doc       string
+	Constants []generatedConstant
+}
+
+// generatedConstant is a single option of a select field
+type generatedConstant struct {
+	Name  string
+	Value string
+}
+
+// Generate emits a formatted Go file declaring the settings of every filter type in options
+func Generate(options path.FiltersOptions, config Config) ([]byte, error) {
+	if config.Package == "" {
+		return nil, fmt.Errorf("a package name is required")
+	}
+
+	if config.Command == "" {
+		config.Command = "path-filtergen"
+	}
+
+	sorted := append([]path.FilterOption(nil), options.Filters...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	declared := map[string]string{}
+	declare := func(name, owner string) error {
+		if other, ok := declared[name]; ok {
+			return fmt.Errorf("%s and %s both generate the identifier %s", other, owner, name)
+		}
+		declared[name] = owner
+		return nil
+	}
+
+	var filters []generatedFilter
+	for _, option := range sorted {
+		filter, err := generateFilter(option, declare)
+		if err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, filter)
+	}
+
+	var source bytes.Buffer
+	err := fileTemplate.Execute(&source, struct {
+		Config  Config
+		Filters []generatedFilter
+	}{config, filters})
+	if err != nil {
+		return nil, err
+	}
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+
+	return formatted, nil
+}
+
+// generateFilter builds the declarations of a single filter type
+func generateFilter(option path.FilterOption, declare func(name, owner string) error) (generatedFilter, error) {
+	owner := fmt.Sprintf("filter %q", option.Name)
+
+	schema, err := json.Marshal(option)
+	if err != nil {
+		return generatedFilter{}, err
+	}
+
+	filter := generatedFilter{
+		Type:      option.Name,
+		Struct:    identifier(option.Name) + "Settings",
+		SchemaVar: lowerIdentifier(option.Name) + "Schema",
+		Schema:    fmt.Sprintf("%q", schema),
+	}
+
+	// The schema is embedded as an indented raw string where possible, so that it can be read in the generated file
+	if indented, err := json.MarshalIndent(option, "", "\t"); err == nil && !bytes.ContainsRune(indented, '`') {
+		filter.Schema = "`" + string(indented) + "`"
+	}
+
+	summary := fmt.Sprintf("%s configures the %q application filter", filter.Struct, option.Name)
+	doc := sentences(summary, option.Description)
+	filter.Doc = comment(doc, "")
+
+	for _, name := range []string{filter.Struct, "New" + filter.Struct, filter.SchemaVar} {
+		if err := declare(name, owner); err != nil {
+			return generatedFilter{}, err
+		}
+	}
+
+	fields, params := map[string]bool{}, map[string]bool{}
+	for _, option := range option.Fields {
+		field := generatedField{
+			Name:     fieldIdentifier(option.Name, fields),
+			JSONName: option.Name,
+			Required: option.Required,
+		}
+
+		selectType := filter.Struct[:len(filter.Struct)-len("Settings")] + field.Name
+		field.Type = goType(option.Value, selectType)
+
+		// Optional booleans and integers are pointers so that false and zero can be told apart from a missing value
+		if !option.Required {
+			field.OmitEmpty = true
+			if field.Type == "bool" || field.Type == "int" {
+				field.Type = "*" + field.Type
+			}
+		}
+
+		label := option.Label
+		if label == "" {
+			label = option.Name
+		}
+
+		required := ""
+		if option.Required {
+			required = "Required"
+		}
+
+		text := sentences(field.Name+" is the "+lowerFirst(label), option.Description, constraint(option.Value), required)
+		field.Doc = comment(text, "\t")
+
+		if selectField, ok := selectValue(option.Value); ok {
+			generated := generatedSelect{
+				Name: selectType,
+				Doc: comment(
+					fmt.Sprintf("%s is a value of the %s field of the %q filter", selectType, option.Name, filter.Type), "",
+				),
+			}
+
+			if err := declare(selectType, owner); err != nil {
+				return generatedFilter{}, err
+			}
+
+			for _, selectOption := range selectField.Options {
+				constant := generatedConstant{
+					Name:  selectType + identifier(selectOption.Value),
+					Value: fmt.Sprintf("%q", selectOption.Value),
+				}
+				if err := declare(constant.Name, owner); err != nil {
+					return generatedFilter{}, err
+				}
+				generated.Constants = append(generated.Constants, constant)
+			}
+
+			filter.SelectTypes = append(filter.SelectTypes, generated)
+		}
+
+		if field.Required {
+			// Parameters follow the field names, and are only numbered when a keyword suffix made two of them equal
+			field.Param = lowerIdentifier(field.Name)
+			for n := 2; params[field.Param]; n++ {
+				field.Param = fmt.Sprintf("%s%d", lowerIdentifier(field.Name), n)
+			}
+			params[field.Param] = true
+			filter.Required = append(filter.Required, field)
+		}
+
+		filter.Fields = append(filter.Fields, field)
+	}
+
+	return filter, nil
+}
+
+// selectValue returns the select description of a field or of the elements of an array field
+func selectValue(value path.FilterFieldValue) (path.FilterOptionFieldSelect, bool) {
+	switch field := value.(type) {
+	case path.FilterOptionFieldSelect:
+		return field, true
+	case path.FilterOptionFieldArray:
+		return selectValue(field.Element())
+	default:
+		return path.FilterOptionFieldSelect{}, false
+	}
+}
+
+// lowerFirst lowers the first letter of a description so that it can follow the name of a field
+func lowerFirst(text string) string {
+	runes := []rune(text)
+	if len(runes) > 1 && unicode.IsUpper(runes[0]) && !unicode.IsUpper(runes[1]) {
+		runes[0] = unicode.ToLower(runes[0])
+	}
+
+	return string(runes)
+}
+
+var fileTemplate = template.Must(template.New("file").Parse(`// Code generated by {{.Config.Command}}. DO NOT EDIT.
+
+package {{.Config.Package}}
+
+import (
+	"encoding/json"
+
+	"github.com/path-network/go-path"
+)
+
+{{range .Filters}}
+{{- $filter := .}}
+{{- range .SelectTypes}}
+{{.Doc}}
+type {{.Name}} string
+
+{{if .Constants}}
+// Options of {{.Name}}
+const (
+{{- $select := .Name}}
+{{- range .Constants}}
+	{{.Name}} {{$select}} = {{.Value}}
+{{- end}}
+)
+{{end}}
+{{end}}
+{{.Doc}}
+type {{.Struct}} struct {
+{{- range .Fields}}
+{{.Doc}}
+	{{.Name}} {{.Type}} ` + "`" + `json:"{{.JSONName}}{{if .OmitEmpty}},omitempty{{end}}"` + "`" + `
+{{- end}}
+}
+
+// New{{.Struct}} returns settings with the required fields of the filter set
+func New{{.Struct}}({{range $i, $field := .Required}}{{if $i}}, {{end}}{{.Param}} {{.Type}}{{end}}) {{.Struct}} {
+	return {{.Struct}}{ {{- range $i, $field := .Required}}{{if $i}}, {{end}}{{.Name}}: {{.Param}}{{end -}} }
+}
+
+// FilterType returns the type of the filter configured by the settings
+func ({{.Struct}}) FilterType() string {
+	return "{{.Type}}"
+}
+
+// Validate checks the settings against the schema of the filter
+func (settings {{.Struct}}) Validate() error {
+	return {{.SchemaVar}}.Validate(settings)
+}
+
+// Create validates the settings and creates the filter with them
+func (settings {{.Struct}}) Create(client *path.Client) (path.Filter, error) {
+	if err := settings.Validate(); err != nil {
+		return path.Filter{}, err
+	}
+
+	return client.CreateFilter(settings.FilterType(), settings)
+}
+
+// Update validates the settings and applies them to an existing filter
+func (settings {{.Struct}}) Update(client *path.Client, filterID string) (path.Filter, error) {
+	if err := settings.Validate(); err != nil {
+		return path.Filter{}, err
+	}
+
+	return client.UpdateFilter(settings.FilterType(), filterID, settings)
+}
+
+// {{.SchemaVar}} is the schema the settings were generated from
+var {{.SchemaVar}} = mustFilterOption({{.Schema}})
+{{end}}
+// mustFilterOption decodes the schema of a filter, which was encoded by the generator
+func mustFilterOption(schema string) path.FilterOption {
+	var option path.FilterOption
+	if err := json.Unmarshal([]byte(schema), &option); err != nil {
+		panic(err)
+	}
+
+	return option
+}
+`))
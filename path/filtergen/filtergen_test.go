@@ -0,0 +1,164 @@
+package filtergen
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/path-network/go-path"
+)
+
+const testOptions = `{"filters": [{"name": "minecraft", "label": "Minecraft", "fields": [
+	{"name": "addr", "label": "Address", "description": "The protected network.", "required": true,
+		"value": {"type": "cidr"}},
+	{"name": "max_pps", "label": "Max PPS", "value": {"type": "integer", "min": 1, "max": 100000}},
+	{"name": "burst", "label": "Burst", "value": {"type": "integer", "min": 10}},
+	{"name": "ttl", "label": "TTL", "value": {"type": "integer", "min": 0, "max": 255}},
+	{"name": "mode", "label": "Mode", "value": {"type": "select", "options": [{"label": "Strict", "value": "strict"}]}},
+	{"name": "allow_ips", "label": "Allowed IPs", "value": {"type": "array", "subtype": "ip"}}
+]}]}`
+
+// pathStub declares the parts of this module used by generated code, so that type-checking does not have to build the
+// module from source
+const pathStub = `package path
+
+type Client struct{}
+
+type Filter struct{}
+
+type FilterOption struct{}
+
+func (FilterOption) Validate(settings interface{}) error { return nil }
+
+func (*Client) CreateFilter(filterType string, settings interface{}) (Filter, error) { return Filter{}, nil }
+
+func (*Client) UpdateFilter(filterType, filterID string, settings interface{}) (Filter, error) { return Filter{}, nil }
+`
+
+// stubImporter imports this module as pathStub, and the standard library from its export data
+type stubImporter struct {
+	fset *token.FileSet
+}
+
+func (stub stubImporter) Import(importPath string) (*types.Package, error) {
+	if importPath != "github.com/path-network/go-path" {
+		return importer.Default().Import(importPath)
+	}
+
+	file, err := parser.ParseFile(stub.fset, "path.go", pathStub, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return (&types.Config{}).Check(importPath, stub.fset, []*ast.File{file}, nil)
+}
+
+// typeCheck parses and type-checks generated source against a stub of this module, failing the test if it does not
+// compile
+func typeCheck(t *testing.T, source []byte) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "filters.go", source, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Generated code does not parse: %s\n%s\n", err.Error(), source)
+	}
+
+	config := types.Config{Importer: stubImporter{fset: fset}}
+	if _, err := config.Check("filters", fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("Generated code does not type-check: %s\n%s\n", err.Error(), source)
+	}
+}
+
+// TestGenerate ensures that a settings struct, its select constants, constructor and methods are generated as valid Go
+func TestGenerate(t *testing.T) {
+	var options path.FiltersOptions
+	if err := json.Unmarshal([]byte(testOptions), &options); err != nil {
+		t.Fatalf("Error decoding filter options: %s\n", err.Error())
+	}
+
+	source, err := Generate(options, Config{Package: "filters"})
+	if err != nil {
+		t.Fatalf("Error generating code: %s\n", err.Error())
+	}
+
+	typeCheck(t, source)
+
+	expected := []string{
+		"// Code generated by path-filtergen. DO NOT EDIT.",
+		"package filters",
+		"MinecraftModeStrict MinecraftMode = \"strict\"",
+		"\t// Addr is the address. The protected network. A network prefix such as 203.0.113.0/24. Required\n" +
+			"\tAddr string `json:\"addr\"`",
+		"MaxPPS *int `json:\"max_pps,omitempty\"`",
+		"// Burst is the burst. An integer of at least 10",
+		"// TTL is the TTL. An integer within [0, 255]",
+		"Mode MinecraftMode `json:\"mode,omitempty\"`",
+		"AllowIPs []string `json:\"allow_ips,omitempty\"`",
+		"func NewMinecraftSettings(addr string) MinecraftSettings {",
+		"return client.CreateFilter(settings.FilterType(), settings)",
+	}
+	for _, declaration := range expected {
+		if !strings.Contains(string(source), declaration) {
+			t.Errorf("Expected %+v, got %+v\n", declaration, string(source))
+		}
+	}
+}
+
+// TestGenerateClash ensures that filters generating the same identifier are reported instead of producing code which
+// does not compile
+func TestGenerateClash(t *testing.T) {
+	options := path.FiltersOptions{Filters: []path.FilterOption{{Name: "game-server"}, {Name: "game_server"}}}
+
+	if _, err := Generate(options, Config{Package: "filters"}); err == nil {
+		t.Errorf("Expected an error for clashing identifiers\n")
+	}
+}
+
+// TestGenerateFieldClash ensures that fields whose names differ only by punctuation are given distinct identifiers
+func TestGenerateFieldClash(t *testing.T) {
+	options := path.FiltersOptions{Filters: []path.FilterOption{{Name: "game", Fields: []path.FilterOptionField{
+		{Name: "dst-port", Value: path.FilterOptionFieldPortRange{}, Required: true},
+		{Name: "dst_port", Value: path.FilterOptionFieldPortRange{}, Required: true},
+		{Name: "validate", Value: path.FilterOptionFieldBool{}},
+	}}}}
+
+	source, err := Generate(options, Config{Package: "filters"})
+	if err != nil {
+		t.Fatalf("Error generating code: %s\n", err.Error())
+	}
+
+	typeCheck(t, source)
+
+	expected := []string{
+		"DstPort string `json:\"dst-port\"`",
+		"DstPort2 string `json:\"dst_port\"`",
+		"ValidateField *bool `json:\"validate,omitempty\"`",
+		"func NewGameSettings(dstPort string, dstPort2 string) GameSettings {",
+	}
+	for _, declaration := range expected {
+		if !strings.Contains(string(source), declaration) {
+			t.Errorf("Expected %+v, got %+v\n", declaration, string(source))
+		}
+	}
+}
+
+// TestIdentifier ensures that names are converted into idiomatic identifiers
+func TestIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"max_pps":   "MaxPPS",
+		"source-ip": "SourceIP",
+		"allow_ips": "AllowIPs",
+		"2fa":       "X2fa",
+		"minecraft": "Minecraft",
+	}
+
+	for name, expected := range tests {
+		if got := identifier(name); got != expected {
+			t.Errorf("Expected %+v, got %+v\n", expected, got)
+		}
+	}
+}
@@ -1,5 +1,11 @@
 package path
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
 type Filters struct {
 	Filters []Filter `json:"filters"`
 }
@@ -7,6 +13,82 @@ type Filters struct {
 type Filter struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
+	// Settings holds the configured value of each field of the filter. The API returns them alongside the ID and name
+	Settings FilterSettings `json:"-"`
+}
+
+// FilterSettings maps the name of each field of an application filter to its value
+type FilterSettings map[string]interface{}
+
+// UnmarshalJSON decodes the ID and name of the filter, and collects every other field into its settings. Numeric IDs
+// are decoded as their decimal representation
+func (filter *Filter) UnmarshalJSON(data []byte) error {
+	var fields map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return err
+	}
+
+	*filter = Filter{}
+	for name, value := range fields {
+		value = numbersToFloat(value)
+
+		switch name {
+		case "id":
+			switch id := fields[name].(type) {
+			case string:
+				filter.ID = id
+			case json.Number:
+				filter.ID = id.String()
+			case nil:
+			default:
+				return fmt.Errorf("invalid filter ID %v: expected a string or a number", id)
+			}
+		case "name":
+			filter.Name, _ = value.(string)
+		default:
+			if filter.Settings == nil {
+				filter.Settings = FilterSettings{}
+			}
+			filter.Settings[name] = value
+		}
+	}
+
+	return nil
+}
+
+// numbersToFloat converts the json.Number values decoded within a value into float64, as encoding/json decodes them
+// by default
+func numbersToFloat(value interface{}) interface{} {
+	switch value := value.(type) {
+	case json.Number:
+		number, _ := value.Float64()
+		return number
+	case map[string]interface{}:
+		for key, element := range value {
+			value[key] = numbersToFloat(element)
+		}
+	case []interface{}:
+		for i, element := range value {
+			value[i] = numbersToFloat(element)
+		}
+	}
+
+	return value
+}
+
+// MarshalJSON encodes the filter as a single object holding its ID, name and settings
+func (filter Filter) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]interface{}, len(filter.Settings)+2)
+	for name, value := range filter.Settings {
+		fields[name] = value
+	}
+
+	fields["id"] = filter.ID
+	fields["name"] = filter.Name
+
+	return json.Marshal(fields)
 }
 
 type FiltersOptions struct {
@@ -20,13 +102,166 @@ type FilterOption struct {
 	Fields      []FilterOptionField `json:"fields"`
 }
 
+// FilterOptionField describes a single setting of an application filter. Value holds one of the FilterOptionField*
+// types, chosen by the "type" of the value returned by the API, so the kind of a field can be found with a type switch:
+//
+//	switch value := field.Value.(type) {
+//	case FilterOptionFieldInteger:
+//		fmt.Println(value.Min != nil, value.Max != nil)
+//	case FilterOptionFieldSelect:
+//		fmt.Println(value.Options)
+//	}
 type FilterOptionField struct {
-	Name        string      `json:"name"`
-	Label       string      `json:"label"`
-	Description string      `json:"description"`
-	Value       interface{} `json:"value"`
+	Name        string           `json:"name"`
+	Label       string           `json:"label"`
+	Description string           `json:"description"`
+	Value       FilterFieldValue `json:"value"`
+	// Required is set for fields which must be given a value when the filter is created
+	Required bool `json:"required,omitempty"`
+}
+
+// Types of values accepted by the fields of application filters
+const (
+	FilterFieldArray     = "array"
+	FilterFieldBool      = "bool"
+	FilterFieldCIDR      = "cidr"
+	FilterFieldIP        = "ip"
+	FilterFieldInteger   = "integer"
+	FilterFieldPortRange = "port_range"
+	FilterFieldSelect    = "select"
+	FilterFieldString    = "string"
+)
+
+// FilterFieldValue describes the values accepted by a field of an application filter
+type FilterFieldValue interface {
+	// FieldType returns the type discriminator of the value, such as FilterFieldInteger
+	FieldType() string
+}
+
+// UnmarshalJSON decodes the field, choosing the type of its value by its "type" discriminator. Values of an unknown type
+// are kept as a FilterOptionFieldUnknown
+func (field *FilterOptionField) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Name        string          `json:"name"`
+		Label       string          `json:"label"`
+		Description string          `json:"description"`
+		Value       json.RawMessage `json:"value"`
+		Required    bool            `json:"required"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	value, err := decodeFilterFieldValue(raw.Value)
+	if err != nil {
+		return fmt.Errorf("field %q: %v", raw.Name, err)
+	}
+
+	*field = FilterOptionField{
+		Name: raw.Name, Label: raw.Label, Description: raw.Description, Value: value, Required: raw.Required,
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes the field. The "type" of its value is always set from FieldType, so values can be built without
+// filling in their Type
+func (field FilterOptionField) MarshalJSON() ([]byte, error) {
+	value, err := encodeFilterFieldValue(field.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(struct {
+		Name        string          `json:"name"`
+		Label       string          `json:"label"`
+		Description string          `json:"description"`
+		Value       json.RawMessage `json:"value"`
+		Required    bool            `json:"required,omitempty"`
+	}{field.Name, field.Label, field.Description, value, field.Required})
+}
+
+// decodeFilterFieldValue decodes a field value into the type named by its discriminator
+func decodeFilterFieldValue(data json.RawMessage) (FilterFieldValue, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+
+	var discriminator struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &discriminator); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch discriminator.Type {
+	case FilterFieldArray:
+		var value FilterOptionFieldArray
+		err = json.Unmarshal(data, &value)
+		return value, err
+	case FilterFieldBool:
+		var value FilterOptionFieldBool
+		err = json.Unmarshal(data, &value)
+		return value, err
+	case FilterFieldCIDR:
+		var value FilterOptionFieldCIDR
+		err = json.Unmarshal(data, &value)
+		return value, err
+	case FilterFieldIP:
+		var value FilterOptionFieldIP
+		err = json.Unmarshal(data, &value)
+		return value, err
+	case FilterFieldInteger:
+		var value FilterOptionFieldInteger
+		err = json.Unmarshal(data, &value)
+		return value, err
+	case FilterFieldPortRange:
+		var value FilterOptionFieldPortRange
+		err = json.Unmarshal(data, &value)
+		return value, err
+	case FilterFieldSelect:
+		var value FilterOptionFieldSelect
+		err = json.Unmarshal(data, &value)
+		return value, err
+	case FilterFieldString:
+		var value FilterOptionFieldString
+		err = json.Unmarshal(data, &value)
+		return value, err
+	default:
+		return FilterOptionFieldUnknown{Type: discriminator.Type, Raw: append(json.RawMessage(nil), data...)}, nil
+	}
+}
+
+// encodeFilterFieldValue encodes a field value with its "type" set from FieldType
+func encodeFilterFieldValue(value FilterFieldValue) (json.RawMessage, error) {
+	if value == nil {
+		return json.RawMessage("null"), nil
+	}
+
+	if unknown, ok := value.(FilterOptionFieldUnknown); ok {
+		if len(unknown.Raw) > 0 {
+			return unknown.Raw, nil
+		}
+		return json.Marshal(map[string]string{"type": unknown.Type})
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	fields["type"], _ = json.Marshal(value.FieldType())
+
+	return json.Marshal(fields)
 }
 
+// FilterOptionFieldArray accepts a list of values of the type named by Subtype
 type FilterOptionFieldArray struct {
 	Type      string `json:"type"`
 	Subtype   string `json:"subtype"`
@@ -34,39 +269,113 @@ type FilterOptionFieldArray struct {
 	MaxLength int    `json:"max_length"`
 }
 
+// FieldType returns FilterFieldArray
+func (FilterOptionFieldArray) FieldType() string {
+	return FilterFieldArray
+}
+
+// Element returns the description of the values within the array, or a FilterOptionFieldUnknown if its subtype is not
+// known
+func (field FilterOptionFieldArray) Element() FilterFieldValue {
+	data, _ := json.Marshal(map[string]string{"type": field.Subtype})
+	value, err := decodeFilterFieldValue(data)
+	if err != nil || value == nil {
+		return FilterOptionFieldUnknown{Type: field.Subtype}
+	}
+
+	return value
+}
+
+// FilterOptionFieldBool accepts true or false
 type FilterOptionFieldBool struct {
 	Type string `json:"type"`
 }
 
+// FieldType returns FilterFieldBool
+func (FilterOptionFieldBool) FieldType() string {
+	return FilterFieldBool
+}
+
+// FilterOptionFieldCIDR accepts a network prefix such as "203.0.113.0/24"
 type FilterOptionFieldCIDR struct {
 	Type string `json:"type"`
 }
 
+// FieldType returns FilterFieldCIDR
+func (FilterOptionFieldCIDR) FieldType() string {
+	return FilterFieldCIDR
+}
+
+// FilterOptionFieldIP accepts a single IP address
 type FilterOptionFieldIP struct {
 	Type string `json:"type"`
 }
 
+// FieldType returns FilterFieldIP
+func (FilterOptionFieldIP) FieldType() string {
+	return FilterFieldIP
+}
+
+// FilterOptionFieldInteger accepts an integer within [Min, Max]. A nil Min or Max, missing from the schema, leaves that
+// side unbounded
 type FilterOptionFieldInteger struct {
 	Type string `json:"type"`
-	Min  int    `json:"min"`
-	Max  int    `json:"max"`
+	Min  *int   `json:"min"`
+	Max  *int   `json:"max"`
+}
+
+// FieldType returns FilterFieldInteger
+func (FilterOptionFieldInteger) FieldType() string {
+	return FilterFieldInteger
 }
 
+// FilterOptionFieldPortRange accepts a port or a range of ports such as "27015-27030"
 type FilterOptionFieldPortRange struct {
 	Type string `json:"type"`
 }
 
+// FieldType returns FilterFieldPortRange
+func (FilterOptionFieldPortRange) FieldType() string {
+	return FilterFieldPortRange
+}
+
+// FilterOptionFieldSelect accepts the value of one of its options
 type FilterOptionFieldSelect struct {
-	Type string `json:"type"`
+	Type    string                          `json:"type"`
+	Options []FilterOptionFieldSelectOption `json:"options"`
 }
 
+// FieldType returns FilterFieldSelect
+func (FilterOptionFieldSelect) FieldType() string {
+	return FilterFieldSelect
+}
+
+// FilterOptionFieldSelectOption is a choice of a select field
 type FilterOptionFieldSelectOption struct {
 	Label string `json:"label"`
 	Value string `json:"value"`
 }
 
+// FilterOptionFieldString accepts text of a length within [MinLength, MaxLength]
 type FilterOptionFieldString struct {
 	Type      string `json:"type"`
 	MinLength int    `json:"min_length"`
 	MaxLength int    `json:"max_length"`
 }
+
+// FieldType returns FilterFieldString
+func (FilterOptionFieldString) FieldType() string {
+	return FilterFieldString
+}
+
+// FilterOptionFieldUnknown holds a value whose type is not known to this package. Raw is the value as it was returned
+// by the API, and is encoded back unchanged
+type FilterOptionFieldUnknown struct {
+	Type string
+	Raw  json.RawMessage
+}
+
+// FieldType returns the type discriminator of the value
+func (field FilterOptionFieldUnknown) FieldType() string {
+	return field.Type
+}
@@ -0,0 +1,134 @@
+package path
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// TestCreateFilter ensures that filter settings are sent as a JSON object, and that the settings of the created filter
+// are decoded alongside its ID and name
+func TestCreateFilter(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"POST /filters/minecraft":    {http.StatusOK, `{"id": "f1", "name": "minecraft", "addr": "203.0.113.5/32", "port": 25565}`},
+		"POST /filters/minecraft/f1": {http.StatusOK, `{"id": "f1", "name": "minecraft", "port": 25566}`},
+	})
+	defer closeAPI()
+
+	created, err := client.CreateFilter("minecraft", FilterSettings{"addr": "203.0.113.5/32", "port": 25565})
+	if err != nil {
+		t.Fatalf("Error creating filter: %s\n", err.Error())
+	}
+
+	expected := Filter{ID: "f1", Name: "minecraft", Settings: FilterSettings{"addr": "203.0.113.5/32", "port": 25565.0}}
+	if !reflect.DeepEqual(created, expected) {
+		t.Errorf("Expected %+v, got %+v\n", expected, created)
+	}
+
+	settings := struct {
+		Port int `json:"port"`
+	}{25566}
+	if _, err := client.UpdateFilter("minecraft", "f1", settings); err != nil {
+		t.Fatalf("Error updating filter: %s\n", err.Error())
+	}
+
+	expectedBodies := []string{`{"addr":"203.0.113.5/32","port":25565}`, `{"port":25566}`}
+	if !reflect.DeepEqual(api.bodies, expectedBodies) {
+		t.Errorf("Expected %+v, got %+v\n", expectedBodies, api.bodies)
+	}
+}
+
+// TestCreateFilterNilSettings ensures that settings encoding to null are rejected instead of being posted
+func TestCreateFilterNilSettings(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{})
+	defer closeAPI()
+
+	var settings *struct {
+		Port int `json:"port"`
+	}
+	for _, value := range []interface{}{nil, settings, FilterSettings(nil)} {
+		if _, err := client.CreateFilter("minecraft", value); err == nil {
+			t.Errorf("Expected an error for %#v\n", value)
+		}
+	}
+
+	if len(api.requests) != 0 {
+		t.Errorf("Unexpected requests: %+v\n", api.requests)
+	}
+}
+
+// TestFilterID ensures that numeric filter IDs are decoded, and that IDs of any other type are reported
+func TestFilterID(t *testing.T) {
+	var filter Filter
+	if err := json.Unmarshal([]byte(`{"id": 9007199254740993, "name": "minecraft", "port": 25565}`), &filter); err != nil {
+		t.Fatalf("Error unmarshalling JSON into struct: %s\n", err.Error())
+	}
+
+	expected := Filter{ID: "9007199254740993", Name: "minecraft", Settings: FilterSettings{"port": 25565.0}}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Errorf("Expected %+v, got %+v\n", expected, filter)
+	}
+
+	if err := json.Unmarshal([]byte(`{"id": true}`), &filter); err == nil {
+		t.Errorf("Expected an error for a boolean ID\n")
+	}
+}
+
+// TestGetFilterOptions ensures that the schema of the filters is fetched from the options endpoint
+func TestGetFilterOptions(t *testing.T) {
+	client, _, closeAPI := newTestClient(t, map[string]mockResponse{
+		"GET /filters/options": {http.StatusOK, `{"filters": [{"name": "minecraft", "label": "Minecraft", "fields": [
+			{"name": "port", "label": "Port", "value": {"type": "integer", "min": 1, "max": 65535}}]}]}`},
+	})
+	defer closeAPI()
+
+	options, err := client.GetFilterOptions()
+	if err != nil {
+		t.Fatalf("Error fetching filter options: %s\n", err.Error())
+	}
+
+	if len(options.Filters) != 1 || len(options.Filters[0].Fields) != 1 || options.Filters[0].Fields[0].Name != "port" {
+		t.Errorf("Unexpected filter options: %+v\n", options)
+	}
+}
+
+// TestFilterOptionFieldValues ensures that field values are decoded into the type named by their discriminator, and
+// encoded back to the same JSON
+func TestFilterOptionFieldValues(t *testing.T) {
+	body := `{"name":"game","label":"Game","description":"","fields":[` +
+		`{"name":"ports","label":"Ports","description":"","value":{"max_length":4,"min_length":1,"subtype":"port_range","type":"array"}},` +
+		`{"name":"mode","label":"Mode","description":"","value":{"options":[{"label":"Strict","value":"strict"}],"type":"select"}},` +
+		`{"name":"limit","label":"Limit","description":"","value":{"max":100,"min":1,"type":"integer"}},` +
+		`{"name":"future","label":"Future","description":"","value":{"type":"geo","regions":["eu"]}}]}`
+
+	var option FilterOption
+	if err := json.Unmarshal([]byte(body), &option); err != nil {
+		t.Fatalf("Error decoding filter option: %s\n", err.Error())
+	}
+
+	expected := []FilterFieldValue{
+		FilterOptionFieldArray{Type: "array", Subtype: "port_range", MinLength: 1, MaxLength: 4},
+		FilterOptionFieldSelect{Type: "select", Options: []FilterOptionFieldSelectOption{{Label: "Strict", Value: "strict"}}},
+		FilterOptionFieldInteger{Type: "integer", Min: intPointer(1), Max: intPointer(100)},
+		FilterOptionFieldUnknown{Type: "geo", Raw: json.RawMessage(`{"type":"geo","regions":["eu"]}`)},
+	}
+	for i, field := range option.Fields {
+		if !reflect.DeepEqual(field.Value, expected[i]) {
+			t.Errorf("Expected %+v, got %+v\n", expected[i], field.Value)
+		}
+	}
+
+	if element := option.Fields[0].Value.(FilterOptionFieldArray).Element(); element.FieldType() != FilterFieldPortRange {
+		t.Errorf("Expected %+v, got %+v\n", FilterFieldPortRange, element)
+	}
+
+	encoded, err := json.Marshal(option)
+	if err != nil {
+		t.Fatalf("Error encoding filter option: %s\n", err.Error())
+	}
+
+	if string(encoded) != body {
+		t.Errorf("Expected %+v, got %+v\n", body, string(encoded))
+	}
+}
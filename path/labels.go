@@ -0,0 +1,268 @@
+package path
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Labels are key/value pairs embedded in the comment of a rule or rate limiter. They allow tooling to record metadata,
+// such as the owning team or ticket, without losing the human-readable part of the comment.
+//
+// Labels are stored in a block at the end of the comment, e.g. "Block scanner [owner=netops,ticket=INC-42]". Characters
+// which would break up the block are percent-encoded
+type Labels map[string]string
+
+// ParseComment separates a comment into its human-readable text and its labels. A comment which does not end in a
+// well-formed label block is returned as-is with no labels
+func ParseComment(comment string) (string, Labels) {
+	labels := Labels{}
+
+	if !strings.HasSuffix(comment, "]") {
+		return comment, labels
+	}
+
+	start := strings.LastIndex(comment, "[")
+	if start == -1 || (start > 0 && comment[start-1] != ' ') {
+		return comment, labels
+	}
+
+	for _, pair := range strings.Split(comment[start+1:len(comment)-1], ",") {
+		separator := strings.Index(pair, "=")
+		if separator < 1 {
+			return comment, Labels{}
+		}
+
+		key, err := url.PathUnescape(pair[:separator])
+		if err != nil {
+			return comment, Labels{}
+		}
+
+		value, err := url.PathUnescape(pair[separator+1:])
+		if err != nil {
+			return comment, Labels{}
+		}
+
+		labels[key] = value
+	}
+
+	return strings.TrimSuffix(comment[:start], " "), labels
+}
+
+// FormatComment is the inverse of ParseComment. Labels are written in key order so that the resulting comment is
+// stable
+func FormatComment(text string, labels Labels) string {
+	if len(labels) == 0 {
+		return text
+	}
+
+	block := "[" + labels.String() + "]"
+	if text == "" {
+		return block
+	}
+
+	return text + " " + block
+}
+
+// String returns the labels as comma-separated key=value pairs in key order
+func (labels Labels) String() string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = fmt.Sprintf("%s=%s", escapeLabel(key), escapeLabel(labels[key]))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// escapeLabel percent-encodes the characters that would otherwise break up a label block
+func escapeLabel(value string) string {
+	var escaped strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte("%,=[]", c) != -1 {
+			fmt.Fprintf(&escaped, "%%%02X", c)
+			continue
+		}
+
+		escaped.WriteByte(c)
+	}
+
+	return escaped.String()
+}
+
+// Labels returns the labels stored in the rule's comment
+func (rule Rule) Labels() Labels {
+	_, labels := ParseComment(rule.Comment)
+	return labels
+}
+
+// CommentText returns the human-readable part of the rule's comment, without its labels
+func (rule Rule) CommentText() string {
+	text, _ := ParseComment(rule.Comment)
+	return text
+}
+
+// SetLabel stores a label in the rule's comment, replacing any previous value for the same key
+func (rule *Rule) SetLabel(key, value string) {
+	text, labels := ParseComment(rule.Comment)
+	labels[key] = value
+	rule.Comment = FormatComment(text, labels)
+}
+
+// DeleteLabel removes a label from the rule's comment
+func (rule *Rule) DeleteLabel(key string) {
+	text, labels := ParseComment(rule.Comment)
+	delete(labels, key)
+	rule.Comment = FormatComment(text, labels)
+}
+
+// Labels returns the labels stored in the rate limiter's comment
+func (rateLimiter RateLimiter) Labels() Labels {
+	_, labels := ParseComment(rateLimiter.Comment)
+	return labels
+}
+
+// CommentText returns the human-readable part of the rate limiter's comment, without its labels
+func (rateLimiter RateLimiter) CommentText() string {
+	text, _ := ParseComment(rateLimiter.Comment)
+	return text
+}
+
+// SetLabel stores a label in the rate limiter's comment, replacing any previous value for the same key
+func (rateLimiter *RateLimiter) SetLabel(key, value string) {
+	text, labels := ParseComment(rateLimiter.Comment)
+	labels[key] = value
+	rateLimiter.Comment = FormatComment(text, labels)
+}
+
+// DeleteLabel removes a label from the rate limiter's comment
+func (rateLimiter *RateLimiter) DeleteLabel(key string) {
+	text, labels := ParseComment(rateLimiter.Comment)
+	delete(labels, key)
+	rateLimiter.Comment = FormatComment(text, labels)
+}
+
+// Selector matches labels against a set of requirements, all of which must be satisfied
+type Selector []SelectorRequirement
+
+// SelectorRequirement is a single condition of a Selector
+type SelectorRequirement struct {
+	Key      string
+	Operator SelectorOperator
+	// Value is ignored by the Exists and DoesNotExist operators
+	Value string
+}
+
+// SelectorOperator determines how a SelectorRequirement compares a label
+type SelectorOperator string
+
+// Operators supported by selectors
+const (
+	SelectorEquals       SelectorOperator = "="
+	SelectorNotEquals    SelectorOperator = "!="
+	SelectorExists       SelectorOperator = "exists"
+	SelectorDoesNotExist SelectorOperator = "!exists"
+)
+
+// ParseSelector parses a comma-separated list of requirements, such as "team=game,env!=staging,ticket,!managed-by".
+// A bare key requires the label to be present, and a key prefixed with "!" requires it to be absent. An empty string
+// yields a selector which matches everything
+func ParseSelector(selector string) (Selector, error) {
+	var parsed Selector
+
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		var requirement SelectorRequirement
+		switch {
+		case strings.Contains(term, "!="):
+			parts := strings.SplitN(term, "!=", 2)
+			requirement = SelectorRequirement{Key: parts[0], Operator: SelectorNotEquals, Value: parts[1]}
+		case strings.Contains(term, "=="):
+			parts := strings.SplitN(term, "==", 2)
+			requirement = SelectorRequirement{Key: parts[0], Operator: SelectorEquals, Value: parts[1]}
+		case strings.Contains(term, "="):
+			parts := strings.SplitN(term, "=", 2)
+			requirement = SelectorRequirement{Key: parts[0], Operator: SelectorEquals, Value: parts[1]}
+		case strings.HasPrefix(term, "!"):
+			requirement = SelectorRequirement{Key: term[1:], Operator: SelectorDoesNotExist}
+		default:
+			requirement = SelectorRequirement{Key: term, Operator: SelectorExists}
+		}
+
+		requirement.Key = strings.TrimSpace(requirement.Key)
+		requirement.Value = strings.TrimSpace(requirement.Value)
+		if requirement.Key == "" {
+			return nil, fmt.Errorf("invalid selector term %q: missing label key", term)
+		}
+
+		parsed = append(parsed, requirement)
+	}
+
+	return parsed, nil
+}
+
+// Matches reports whether the labels satisfy every requirement of the selector
+func (selector Selector) Matches(labels Labels) bool {
+	for _, requirement := range selector {
+		value, ok := labels[requirement.Key]
+
+		switch requirement.Operator {
+		case SelectorEquals:
+			if !ok || value != requirement.Value {
+				return false
+			}
+		case SelectorNotEquals:
+			if ok && value == requirement.Value {
+				return false
+			}
+		case SelectorExists:
+			if !ok {
+				return false
+			}
+		case SelectorDoesNotExist:
+			if ok {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// Select returns the rules whose labels match the selector
+func (rules Rules) Select(selector Selector) []Rule {
+	var selected []Rule
+	for _, rule := range rules.Rules {
+		if selector.Matches(rule.Labels()) {
+			selected = append(selected, rule)
+		}
+	}
+
+	return selected
+}
+
+// Select returns the rate limiters whose labels match the selector
+func (rateLimiters RateLimiters) Select(selector Selector) []RateLimiter {
+	var selected []RateLimiter
+	for _, rateLimiter := range rateLimiters.RateLimiters {
+		if selector.Matches(rateLimiter.Labels()) {
+			selected = append(selected, rateLimiter)
+		}
+	}
+
+	return selected
+}
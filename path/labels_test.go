@@ -0,0 +1,82 @@
+package path
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseComment ensures that labels and the human-readable text are separated correctly
+func TestParseComment(t *testing.T) {
+	cases := []struct {
+		comment        string
+		expectedText   string
+		expectedLabels Labels
+	}{
+		{"Block scanner", "Block scanner", Labels{}},
+		{"Block scanner [owner=netops,ticket=INC-42]", "Block scanner", Labels{"owner": "netops", "ticket": "INC-42"}},
+		{"[managed-by=terraform]", "", Labels{"managed-by": "terraform"}},
+		{"See [INC-42]", "See [INC-42]", Labels{}},
+		{"Odd[owner=netops]", "Odd[owner=netops]", Labels{}},
+		{"Escaped [note=a%2Cb%20c]", "Escaped", Labels{"note": "a,b c"}},
+	}
+
+	for _, c := range cases {
+		text, labels := ParseComment(c.comment)
+		if text != c.expectedText || !reflect.DeepEqual(labels, c.expectedLabels) {
+			t.Errorf("%q: expected %q %v, got %q %v\n", c.comment, c.expectedText, c.expectedLabels, text, labels)
+		}
+	}
+}
+
+// TestSetLabel ensures that labels round-trip through the comment without altering its text
+func TestSetLabel(t *testing.T) {
+	rule := Rule{Comment: "Game server"}
+	rule.SetLabel("team", "game")
+	rule.SetLabel("note", "a,b=c]")
+
+	const expected = "Game server [note=a%2Cb%3Dc%5D,team=game]"
+	if rule.Comment != expected {
+		t.Errorf("Expected %q, got %q\n", expected, rule.Comment)
+	}
+
+	if got := rule.Labels()["note"]; got != "a,b=c]" {
+		t.Errorf("Expected %q, got %q\n", "a,b=c]", got)
+	}
+
+	rule.DeleteLabel("note")
+	rule.DeleteLabel("team")
+	if rule.Comment != "Game server" {
+		t.Errorf("Expected %q, got %q\n", "Game server", rule.Comment)
+	}
+}
+
+// TestSelector ensures that selectors are parsed and matched against labels
+func TestSelector(t *testing.T) {
+	selector, err := ParseSelector("team=game, env!=staging, ticket, !paused")
+	if err != nil {
+		t.Fatalf("Error parsing selector: %s\n", err.Error())
+	}
+
+	rules := Rules{Rules: []Rule{
+		{ID: "match", Comment: "[env=production,team=game,ticket=1]"},
+		{ID: "no-env", Comment: "[team=game,ticket=2]"},
+		{ID: "staging", Comment: "[env=staging,team=game,ticket=3]"},
+		{ID: "paused", Comment: "[paused=true,team=game,ticket=4]"},
+		{ID: "other-team", Comment: "[team=web,ticket=5]"},
+		{ID: "no-ticket", Comment: "[team=game]"},
+	}}
+
+	var got []string
+	for _, rule := range rules.Select(selector) {
+		got = append(got, rule.ID)
+	}
+
+	expected := []string{"match", "no-env"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v\n", expected, got)
+	}
+
+	if _, err := ParseSelector("=game"); err == nil {
+		t.Errorf("Expected an error for a selector without a key\n")
+	}
+}
@@ -0,0 +1,407 @@
+// Package metrics exports the protection state of a Path account in the Prometheus text format. An Exporter
+// periodically fetches the diversions, attack history, rules, rate limiters and filters of the account, and serves
+// the last state it collected as an http.Handler, along with the latency and errors of the API calls it made.
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/path-network/go-path"
+)
+
+// Names of the API calls made by an Exporter, used as the value of the "call" label
+const (
+	CallDiversions    = "diversions"
+	CallAttackHistory = "attack_history"
+	CallRules         = "rules"
+	CallRateLimiters  = "rate_limiters"
+	CallFilters       = "filters"
+)
+
+// DefaultInterval is the time between collections made by Run when Interval is not positive
+const DefaultInterval = time.Minute
+
+// Exporter collects the protection state of an account and serves it as Prometheus metrics. Metrics are only served
+// for calls which succeeded at least once, and keep the values of the last successful call while later calls fail
+type Exporter struct {
+	Client *path.Client
+	// Authenticate returns a newly authenticated client, which replaces Client once its token has expired or after the
+	// API rejected it. The collection is then made again with the new client. If nil, Client is used for as long as it
+	// works
+	Authenticate func() (*path.Client, error)
+	// Interval is the time between collections made by Run. If it is not positive, DefaultInterval is used
+	Interval time.Duration
+	// Clock is used to time the API calls and the collections. If nil, the system clock is used
+	Clock path.Clock
+	// OnError is called with every error encountered by Run, if it is not nil
+	OnError func(error)
+
+	mutex          sync.RWMutex
+	diversions     *path.Diversions
+	attackHistory  *path.AttackHistory
+	rules          *path.Rules
+	rateLimiters   *path.RateLimiters
+	filters        *path.Filters
+	calls          map[string]*callStats
+	lastCollection time.Time
+}
+
+// callStats accumulates the outcome of the calls made to an endpoint
+type callStats struct {
+	requests int
+	errors   int
+	seconds  float64
+}
+
+// clock returns the configured clock, falling back to the system clock
+func (exporter *Exporter) clock() path.Clock {
+	if exporter.Clock == nil {
+		return path.SystemClock{}
+	}
+
+	return exporter.Clock
+}
+
+// Collect fetches the current state of the account. Every call is made even if an earlier one fails, and the first
+// error encountered is returned. Collect must not be called concurrently, as it may replace Client
+func (exporter *Exporter) Collect() error {
+	if exporter.Authenticate != nil && exporter.expired() {
+		if err := exporter.authenticate(); err != nil {
+			return err
+		}
+	}
+
+	err := exporter.collect()
+
+	var responseError *path.ResponseError
+	if exporter.Authenticate != nil && errors.As(err, &responseError) &&
+		responseError.StatusCode == http.StatusUnauthorized {
+		if err := exporter.authenticate(); err != nil {
+			return err
+		}
+
+		err = exporter.collect()
+	}
+
+	return err
+}
+
+// expired reports whether there is no client yet or its token has expired. Tokens without an expiry are used until the
+// API rejects them
+func (exporter *Exporter) expired() bool {
+	if exporter.Client == nil {
+		return true
+	}
+
+	token := exporter.Client.Token()
+
+	return !token.Expiry.IsZero() && !token.Valid(exporter.clock().Now())
+}
+
+// authenticate replaces the client with a newly authenticated one
+func (exporter *Exporter) authenticate() error {
+	client, err := exporter.Authenticate()
+	if err != nil {
+		return fmt.Errorf("authenticating: %w", err)
+	}
+
+	exporter.Client = client
+
+	return nil
+}
+
+// collect makes every call once with the current client
+func (exporter *Exporter) collect() error {
+	var firstErr error
+	record := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	record(exporter.call(CallDiversions, func() (func(), error) {
+		diversions, err := exporter.Client.GetDiversions()
+		return func() { exporter.diversions = &diversions }, err
+	}))
+
+	record(exporter.call(CallAttackHistory, func() (func(), error) {
+		attackHistory, err := exporter.Client.GetAttackHistory()
+		return func() { exporter.attackHistory = &attackHistory }, err
+	}))
+
+	record(exporter.call(CallRules, func() (func(), error) {
+		rules, err := exporter.Client.GetRules()
+		return func() { exporter.rules = &rules }, err
+	}))
+
+	record(exporter.call(CallRateLimiters, func() (func(), error) {
+		rateLimiters, err := exporter.Client.GetRateLimiters()
+		return func() { exporter.rateLimiters = &rateLimiters }, err
+	}))
+
+	record(exporter.call(CallFilters, func() (func(), error) {
+		filters, err := exporter.Client.GetFilters()
+		return func() { exporter.filters = &filters }, err
+	}))
+
+	exporter.mutex.Lock()
+	exporter.lastCollection = exporter.clock().Now()
+	exporter.mutex.Unlock()
+
+	return firstErr
+}
+
+// call times a single API call. fetch makes the request without holding the lock, and returns a function saving its
+// result, which is only run with the lock held if the request succeeded
+func (exporter *Exporter) call(name string, fetch func() (func(), error)) error {
+	start := exporter.clock().Now()
+	save, err := fetch()
+	elapsed := exporter.clock().Now().Sub(start)
+
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+
+	if exporter.calls == nil {
+		exporter.calls = map[string]*callStats{}
+	}
+	if exporter.calls[name] == nil {
+		exporter.calls[name] = &callStats{}
+	}
+
+	stats := exporter.calls[name]
+	stats.requests++
+	stats.seconds += elapsed.Seconds()
+
+	if err != nil {
+		stats.errors++
+		return err
+	}
+
+	save()
+
+	return nil
+}
+
+// Run collects immediately and then every Interval until ctx is cancelled
+func (exporter *Exporter) Run(ctx context.Context) error {
+	interval := exporter.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := exporter.Collect(); err != nil && exporter.OnError != nil {
+			exporter.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ServeHTTP writes the last collected state in the Prometheus text format
+func (exporter *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var metrics textWriter
+
+	exporter.mutex.RLock()
+	exporter.write(&metrics)
+	exporter.mutex.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(metrics.buffer.Bytes())
+}
+
+// write renders every metric. The lock must be held
+func (exporter *Exporter) write(metrics *textWriter) {
+	if exporter.diversions != nil {
+		writeDiversions(metrics, *exporter.diversions)
+	}
+
+	if exporter.attackHistory != nil {
+		writeAttackHistory(metrics, *exporter.attackHistory)
+	}
+
+	if exporter.rules != nil {
+		metrics.family("path_rules", "gauge", "Number of firewall rules")
+		metrics.sample("path_rules", float64(len(exporter.rules.Rules)))
+	}
+
+	if exporter.rateLimiters != nil {
+		metrics.family("path_rate_limiters", "gauge", "Number of rate limiters")
+		metrics.sample("path_rate_limiters", float64(len(exporter.rateLimiters.RateLimiters)))
+	}
+
+	if exporter.filters != nil {
+		metrics.family("path_filters", "gauge", "Number of application filters")
+		metrics.sample("path_filters", float64(len(exporter.filters.Filters)))
+	}
+
+	calls := make([]string, 0, len(exporter.calls))
+	for name := range exporter.calls {
+		calls = append(calls, name)
+	}
+	sort.Strings(calls)
+
+	if len(calls) > 0 {
+		metrics.family("path_api_requests_total", "counter", "Number of requests made to the API, by call")
+		for _, name := range calls {
+			metrics.sample("path_api_requests_total", float64(exporter.calls[name].requests), "call", name)
+		}
+
+		metrics.family("path_api_errors_total", "counter", "Number of requests to the API which failed, by call")
+		for _, name := range calls {
+			metrics.sample("path_api_errors_total", float64(exporter.calls[name].errors), "call", name)
+		}
+
+		metrics.family("path_api_request_duration_seconds", "summary", "Latency of the requests made to the API, by call")
+		for _, name := range calls {
+			metrics.sample("path_api_request_duration_seconds_sum", exporter.calls[name].seconds, "call", name)
+			metrics.sample("path_api_request_duration_seconds_count", float64(exporter.calls[name].requests), "call", name)
+		}
+	}
+
+	if !exporter.lastCollection.IsZero() {
+		metrics.family("path_last_collection_timestamp_seconds", "gauge", "Time at which the last collection finished")
+		metrics.sample("path_last_collection_timestamp_seconds", unixSeconds(exporter.lastCollection))
+	}
+}
+
+// writeDiversions renders the diverted prefixes and the hosts under attack within them
+func writeDiversions(metrics *textWriter, diversions path.Diversions) {
+	sorted := append([]path.Diversion(nil), diversions.Diversions...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Subnet < sorted[j].Subnet
+	})
+
+	metrics.family("path_diverted_prefixes", "gauge", "Number of prefixes currently diverted")
+	metrics.sample("path_diverted_prefixes", float64(len(sorted)))
+
+	metrics.family("path_diversion", "gauge", "Prefixes currently diverted, and whether they were diverted manually")
+	for _, diversion := range sorted {
+		metrics.sample("path_diversion", 1, "prefix", diversion.Subnet, "manual", strconv.FormatBool(diversion.Manual))
+	}
+
+	hosts := 0
+	for _, diversion := range sorted {
+		hosts += len(diversion.UnderAttack)
+	}
+
+	metrics.family("path_hosts_under_attack", "gauge", "Number of hosts currently reported as under attack")
+	metrics.sample("path_hosts_under_attack", float64(hosts))
+
+	metrics.family("path_host_under_attack_since_timestamp_seconds", "gauge",
+		"Time since which a host has been reported as under attack, by prefix and attack vector")
+	for _, diversion := range sorted {
+		underAttack := append([]path.UnderAttack(nil), diversion.UnderAttack...)
+		sort.Slice(underAttack, func(i, j int) bool {
+			return underAttack[i].Host < underAttack[j].Host
+		})
+
+		for _, host := range underAttack {
+			// Without a time since which the host is under attack, there is no sample rather than one in year 1
+			if host.Since.IsZero() {
+				continue
+			}
+
+			metrics.sample("path_host_under_attack_since_timestamp_seconds", unixSeconds(host.Since.Time),
+				"host", host.Host, "prefix", diversion.Subnet, "vector", string(host.Reason.Vector()))
+		}
+	}
+}
+
+// writeAttackHistory renders the number of ongoing attacks and the peaks of the last attack against each host
+func writeAttackHistory(metrics *textWriter, attackHistory path.AttackHistory) {
+	ongoing := 0
+	last := map[string]path.AttackDetails{}
+	for _, attack := range attackHistory.AttackHistory {
+		if attack.End.IsZero() {
+			ongoing++
+		}
+
+		if previous, ok := last[attack.Host]; !ok || attack.Start.After(previous.Start.Time) {
+			last[attack.Host] = attack
+		}
+	}
+
+	hosts := make([]string, 0, len(last))
+	for host := range last {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	metrics.family("path_attacks_ongoing", "gauge", "Number of attacks in the history which have not ended yet")
+	metrics.sample("path_attacks_ongoing", float64(ongoing))
+
+	metrics.family("path_last_attack_start_timestamp_seconds", "gauge", "Start of the last attack against each host")
+	for _, host := range hosts {
+		if last[host].Start.IsZero() {
+			continue
+		}
+
+		metrics.sample("path_last_attack_start_timestamp_seconds", unixSeconds(last[host].Start.Time), "host", host)
+	}
+
+	metrics.family("path_last_attack_peak_bps", "gauge", "Peak bits per second of the last attack against each host")
+	for _, host := range hosts {
+		metrics.sample("path_last_attack_peak_bps", float64(last[host].PeakBPS.Value), "host", host)
+	}
+
+	metrics.family("path_last_attack_peak_pps", "gauge", "Peak packets per second of the last attack against each host")
+	for _, host := range hosts {
+		metrics.sample("path_last_attack_peak_pps", float64(last[host].PeakPPS.Value), "host", host)
+	}
+}
+
+// unixSeconds converts a time to fractional seconds since the Unix epoch. Unlike UnixNano, it is defined for any time
+func unixSeconds(t time.Time) float64 {
+	return float64(t.Unix()) + float64(t.Nanosecond())/float64(time.Second)
+}
+
+// textWriter renders metrics in the Prometheus text exposition format
+type textWriter struct {
+	buffer bytes.Buffer
+}
+
+// family writes the HELP and TYPE lines introducing a metric
+func (writer *textWriter) family(name, metricType, help string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(&writer.buffer, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// sample writes a single value. labels alternate between names and values
+func (writer *textWriter) sample(name string, value float64, labels ...string) {
+	writer.buffer.WriteString(name)
+
+	if len(labels) > 0 {
+		writer.buffer.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				writer.buffer.WriteByte(',')
+			}
+			fmt.Fprintf(&writer.buffer, "%s=\"%s\"", labels[i], escapeLabelValue(labels[i+1]))
+		}
+		writer.buffer.WriteByte('}')
+	}
+
+	fmt.Fprintf(&writer.buffer, " %s\n", strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// escapeLabelValue escapes backslashes, double quotes and line feeds in a label value
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/path-network/go-path"
+)
+
+// fixedClock is a path.Clock which always returns the same time
+type fixedClock time.Time
+
+func (clock fixedClock) Now() time.Time {
+	return time.Time(clock)
+}
+
+// fakeAPIRoutes are the responses of the fake API, keyed by method and path
+var fakeAPIRoutes = map[string]string{
+	"POST /token": `{"access_token": "test", "token_type": "bearer"}`,
+	"GET /diversions": `{"diversion": [{"subnet": "203.0.113.0/24", "manual": false, "under_attack": [
+		{"host": "203.0.113.5", "reason": "UDP flood", "since": "2020-01-01T00:00:00Z"},
+		{"host": "203.0.113.6", "reason": "SYN flood"}]},
+		{"subnet": "198.51.100.0/24", "manual": true, "under_attack": []}]}`,
+	"GET /attack_history": `{"attack_history": [
+		{"host": "203.0.113.5", "reason": "SYN flood", "start": "2019-12-31T00:00:00Z", "end": "2019-12-31T01:00:00Z",
+			"peak_bps": {"value": 900}, "peak_pps": {"value": 10}},
+		{"host": "203.0.113.5", "reason": "UDP flood", "start": "2020-01-01T00:00:00Z",
+			"peak_bps": {"value": 412300000000}, "peak_pps": {"value": 38100000}}]}`,
+	"GET /rules":         `{"rules": [{"id": "1"}, {"id": "2"}]}`,
+	"GET /rate_limiters": `{"rate_limiter": [{"id": "1", "packets_per_second": 5000}]}`,
+}
+
+// newFakeAPI starts a fake of Path's API serving fakeAPIRoutes, and returns a client authenticated against it
+func newFakeAPI(t *testing.T) (*path.Client, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := fakeAPIRoutes[fmt.Sprintf("%s %s", r.Method, r.URL.Path)]
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+
+	client, err := path.NewClient(path.AccessTokenRequest{Username: "foo", Password: "bar"},
+		path.WithBaseURL(server.URL+"/"), path.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("Error authenticating: %s\n", err.Error())
+	}
+
+	return &client, server.Close
+}
+
+// TestExporter ensures that the collected state is served in the Prometheus text format, and that failing calls are
+// counted without discarding the metrics of the calls which succeeded
+func TestExporter(t *testing.T) {
+	client, closeAPI := newFakeAPI(t)
+	defer closeAPI()
+
+	exporter := &Exporter{Client: client, Clock: fixedClock(time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC))}
+	if err := exporter.Collect(); err == nil {
+		t.Errorf("Expected the failing filters call to be reported\n")
+	}
+
+	recorder := httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	got := recorder.Body.String()
+
+	expected := []string{
+		"# TYPE path_diverted_prefixes gauge\npath_diverted_prefixes 2\n",
+		`path_diversion{prefix="198.51.100.0/24",manual="true"} 1`,
+		"path_hosts_under_attack 2\n",
+		`path_host_under_attack_since_timestamp_seconds{host="203.0.113.5",prefix="203.0.113.0/24",vector="udp_flood"} 1.5778368e+09`,
+		"path_attacks_ongoing 1\n",
+		`path_last_attack_peak_bps{host="203.0.113.5"} 4.123e+11`,
+		`path_last_attack_peak_pps{host="203.0.113.5"} 3.81e+07`,
+		"path_rules 2\n",
+		"path_rate_limiters 1\n",
+		`path_api_requests_total{call="filters"} 1`,
+		`path_api_errors_total{call="filters"} 1`,
+		`path_api_errors_total{call="rules"} 0`,
+		`path_api_request_duration_seconds_count{call="diversions"} 1`,
+		"path_last_collection_timestamp_seconds 1.5778404e+09\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(got, line) {
+			t.Errorf("Expected %+v, got %+v\n", line, got)
+		}
+	}
+
+	if strings.Contains(got, `path_host_under_attack_since_timestamp_seconds{host="203.0.113.6"`) {
+		t.Errorf("Expected no sample for a host without a time since which it is under attack, got %+v\n", got)
+	}
+
+	if strings.Contains(got, "path_filters ") {
+		t.Errorf("Expected no filter count before filters were fetched, got %+v\n", got)
+	}
+
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type: %s\n", contentType)
+	}
+}
+
+// TestExporterAuthenticate ensures that a client whose token was rejected is replaced, and the collection made again
+func TestExporterAuthenticate(t *testing.T) {
+	tokens := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tokens++
+			fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "bearer"}`, tokens)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"detail": "expired token"}`)
+			return
+		}
+
+		body, ok := fakeAPIRoutes[fmt.Sprintf("%s %s", r.Method, r.URL.Path)]
+		if !ok {
+			body = `{"filters": []}`
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	authenticate := func() (*path.Client, error) {
+		client, err := path.NewClient(path.AccessTokenRequest{Username: "foo", Password: "bar"},
+			path.WithBaseURL(server.URL+"/"), path.WithHTTPClient(server.Client()))
+		return &client, err
+	}
+
+	client, err := authenticate()
+	if err != nil {
+		t.Fatalf("Error authenticating: %s\n", err.Error())
+	}
+
+	exporter := &Exporter{Client: client, Authenticate: authenticate}
+	if err := exporter.Collect(); err != nil {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+
+	if got := exporter.Client.Token().AccessToken; got != "token-2" {
+		t.Errorf("Expected %+v, got %+v\n", "token-2", got)
+	}
+}
+
+// TestExporterRunInterval ensures that Run falls back to the default interval instead of panicking on a zero one
+func TestExporterRunInterval(t *testing.T) {
+	client, closeAPI := newFakeAPI(t)
+	defer closeAPI()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	exporter := &Exporter{Client: client}
+	if err := exporter.Run(ctx); err != context.Canceled {
+		t.Errorf("Expected %+v, got %+v\n", context.Canceled, err)
+	}
+}
+
+// TestEscapeLabelValue ensures that label values cannot break out of their quotes
+func TestEscapeLabelValue(t *testing.T) {
+	got := escapeLabelValue("a\\b\"c\nd")
+	expected := `a\\b\"c\nd`
+
+	if got != expected {
+		t.Errorf("Expected %+v, got %+v\n", expected, got)
+	}
+}
@@ -0,0 +1,59 @@
+package path
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ClientOption configures a Client created by NewClient
+type ClientOption func(*Client)
+
+// WithBaseURL points the client at another deployment of the API, such as a local fake used in tests. A trailing slash
+// is removed
+func WithBaseURL(baseURL string) ClientOption {
+	return func(client *Client) {
+		client.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithHTTPClient makes the client send its requests through the given HTTP client, e.g. to set timeouts or a proxy
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(client *Client) {
+		client.httpClient = httpClient
+	}
+}
+
+// WithTokenCache makes NewClient reuse a valid token from the cache instead of authenticating, and GetToken store the
+// tokens it receives in the cache. Errors of the cache are ignored, as authenticating again is always possible
+func WithTokenCache(cache TokenCache) ClientOption {
+	return func(client *Client) {
+		client.tokenCache = cache
+	}
+}
+
+// WithCachedTokenOnly makes NewClient return ErrNoCachedToken instead of requesting a token when the token cache holds
+// no valid token, e.g. to log out without logging in first
+func WithCachedTokenOnly() ClientOption {
+	return func(client *Client) {
+		client.cachedTokenOnly = true
+	}
+}
+
+// WithClock makes the client take the current time from clock in helpers acting on expiries, such as
+// CreateRuleWithTTL, and when computing the expiry of its tokens or checking that a cached token is still valid,
+// instead of from the system clock
+func WithClock(clock Clock) ClientOption {
+	return func(client *Client) {
+		client.clock = clock
+	}
+}
+
+// WithWithdrawalRetry sets how DivertFor and DivertDuring retry a failed withdrawal: up to attempts times, waiting
+// backoff after the first failure and twice as long after every further one, for at most maxWait in total. Values which
+// are not positive keep their default of 5 attempts, a backoff of a second and a maximum wait of 30 seconds
+func WithWithdrawalRetry(attempts int, backoff, maxWait time.Duration) ClientOption {
+	return func(client *Client) {
+		client.withdrawalRetry = withdrawalRetry{attempts: attempts, backoff: backoff, maxWait: maxWait}
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 // Client is a REST API client for Path.net
@@ -18,21 +21,46 @@ type Client struct {
 	// Represents the base API URL from which the service may be used by appending endpoints. It must not contain a
 	// trailing slash
 	baseURL string
+	// tokenCache holds tokens shared with other clients, if set with WithTokenCache
+	tokenCache TokenCache
+	// tokenKey is the key of the client's token in the token cache
+	tokenKey string
+	// logger and debug receive a line for each request and a redacted dump of each exchange, if set with WithLogger
+	// and WithDebug
+	logger Logger
+	debug  io.Writer
+	// clock provides the current time to helpers such as CreateRuleWithTTL, if set with WithClock
+	clock Clock
+	// cachedTokenOnly makes NewClient fail instead of requesting a token, if set with WithCachedTokenOnly
+	cachedTokenOnly bool
+	// withdrawalRetry configures the retries of withdrawals made by DivertDuring, if set with WithWithdrawalRetry
+	withdrawalRetry withdrawalRetry
 }
 
 // GetToken attempts to retrieve an access token from Path's API in order to use other endpoints. It will return an
-// error if it does not succeed, otherwise it will set the client's token accordingly.
+// error if it does not succeed, otherwise it will set the client's token accordingly, and store it in the client's
+// token cache if it has one.
 func (client *Client) GetToken(request AccessTokenRequest) error {
+	if err := request.Validate(); err != nil {
+		return err
+	}
+
 	endpoint := client.baseURL + "/token"
 
-	// Unlike the rest of the API which consumes JSON, the /token endpoint expects URL-encoded POST data
-	form := url.Values{
-		"grant_type":    {request.GrantType},
-		"username":      {request.Username},
-		"password":      {request.Password},
-		"scope":         {request.Scope},
-		"client_id":     {request.ClientID},
-		"client_secret": {request.ClientSecret},
+	// Unlike the rest of the API which consumes JSON, the /token endpoint expects URL-encoded POST data. Only the fields
+	// which are set are sent, so that e.g. an empty password is not mistaken for a given one
+	form := url.Values{"grant_type": {request.EffectiveGrantType()}}
+	fields := map[string]string{
+		"username":      request.Username,
+		"password":      request.Password,
+		"scope":         request.Scope,
+		"client_id":     request.ClientID,
+		"client_secret": request.ClientSecret,
+	}
+	for name, value := range fields {
+		if value != "" {
+			form.Set(name, value)
+		}
 	}
 
 	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
@@ -54,12 +82,68 @@ func (client *Client) GetToken(request AccessTokenRequest) error {
 		return err
 	}
 
+	if receivedToken.ExpiresIn > 0 {
+		receivedToken.Expiry = clockOrSystem(client.clock).Now().Add(time.Duration(receivedToken.ExpiresIn) * time.Second)
+	}
+	if receivedToken.Scope == "" {
+		receivedToken.Scope = request.Scope
+	}
+
 	// Set the client's accessToken for subsequent API requests
 	client.token = receivedToken
+	client.tokenKey = TokenCacheKey(client.baseURL, request)
+
+	// The cache only speeds up authentication, so failing to update it does not fail the request
+	if client.tokenCache != nil {
+		client.tokenCache.Store(client.tokenKey, receivedToken)
+	}
 
 	return nil
 }
 
+// Token returns the access token the client authenticates with
+func (client *Client) Token() Token {
+	return client.token
+}
+
+// RevokeToken asks the API to revoke the client's access token, so that it cannot be used anymore
+func (client *Client) RevokeToken() error {
+	endpoint := client.baseURL + "/token/revoke"
+
+	form := url.Values{
+		"token":           {client.token.AccessToken},
+		"token_type_hint": {"access_token"},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	_, err = client.handleRequest(req)
+
+	return err
+}
+
+// Logout revokes the client's access token and forgets it, removing it from the token cache as well if the cache
+// implements TokenCacheDeleter. The token is forgotten even if revoking it fails, in which case the error is returned
+func (client *Client) Logout() error {
+	err := client.RevokeToken()
+
+	if deleter, ok := client.tokenCache.(TokenCacheDeleter); ok && client.tokenKey != "" {
+		if cacheErr := deleter.Delete(client.tokenKey); err == nil {
+			err = cacheErr
+		}
+	}
+
+	client.token = Token{}
+	client.tokenKey = ""
+
+	return err
+}
+
 // GetToken attempts to retrieve an access token from Path's API in order to use other endpoints. It will return an
 // error if it does not succeed, otherwise it will set the client's token accordingly.
 func (client *Client) ChangePassword(oldPassword, newPassword string) error {
@@ -95,60 +179,116 @@ func (client *Client) ChangePassword(oldPassword, newPassword string) error {
 // handleRequest executes the provided request and does all of the error processing. If a successful HTTP status code was received,
 // it returns the clean request body.
 func (client *Client) handleRequest(req *http.Request) ([]byte, error) {
+	resp, err := client.sendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return body, err
+	}
+
+	return body, responseError(resp.StatusCode, body)
+}
+
+// streamRequest executes the provided request like handleRequest, but returns the request body unread so that large
+// responses can be decoded incrementally. The caller must close the returned body
+func (client *Client) streamRequest(req *http.Request) (io.ReadCloser, error) {
+	resp, err := client.sendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusAccepted {
+		return resp.Body, nil
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, responseError(resp.StatusCode, body)
+}
+
+// sendRequest adds the client's authorization to the request and executes it, logging and dumping it if the client was
+// configured to
+func (client *Client) sendRequest(req *http.Request) (*http.Response, error) {
 	if client.token.AccessToken != "" {
 		// Add the authorization if applicable
 		// i.e Authorization: bearer accesstokenhere
 		req.Header.Add("Authorization", fmt.Sprintf("%s %s", client.token.TokenType, client.token.AccessToken))
 	}
 
+	if client.debug != nil {
+		client.dumpRequest(req)
+	}
+
+	start := time.Now()
 	resp, err := client.httpClient.Do(req)
-	if err != nil {
-		return nil, err
+
+	if client.logger != nil {
+		client.logRequest(req, resp, err, time.Since(start))
+	}
+	if client.debug != nil && err == nil {
+		client.dumpResponse(resp)
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return body, err
+	return resp, err
+}
+
+// responseError converts an error response of the API into a *ResponseError. Validation errors of 422 responses are
+// returned as they are, so that callers asserting err.(ValidationError) keep working. It returns nil for successful
+// status codes
+func responseError(statusCode int, body []byte) error {
+	err := responseBodyError(statusCode, body)
+	if err == nil {
+		return nil
 	}
 
-	switch resp.StatusCode {
+	if _, ok := err.(ValidationError); ok {
+		return err
+	}
+
+	return &ResponseError{StatusCode: statusCode, Err: err}
+}
+
+// responseBodyError derives the error described by the body of a response
+func responseBodyError(statusCode int, body []byte) error {
+	switch statusCode {
 	case http.StatusAccepted:
 		fallthrough
 	case http.StatusOK:
 		{
-			return body, err
+			return nil
 		}
 	case http.StatusUnauthorized:
 		{
 			var apiError Error
-			err = json.Unmarshal(body, &apiError)
+			err := json.Unmarshal(body, &apiError)
 
 			if err != nil {
-				return body, err
+				return err
 			}
-			return body, errors.New(apiError.Detail)
+			return errors.New(apiError.Detail)
 		}
 	case http.StatusUnprocessableEntity: // ValidationError
 		{
 			var apiErrors ValidationError
-			err = json.Unmarshal(body, &apiErrors)
+			err := json.Unmarshal(body, &apiErrors)
 
 			if err != nil {
-				return body, err
+				return err
 			}
 
-			var errMsg strings.Builder
-			for _, errEntry := range apiErrors.Detail {
-				errMsg.WriteString(
-					fmt.Sprintf("\n- Message: %s\n  Type: %s\n  Location: %s", errEntry.Msg, errEntry.Type, errEntry.Loc),
-				)
-			}
-
-			return body, errors.New(errMsg.String())
+			return apiErrors
 		}
 	default:
 		{
-			return body, errors.New(fmt.Sprintf("Received unexpected status code: %d", resp.StatusCode))
+			return errors.New(fmt.Sprintf("Received unexpected status code: %d", statusCode))
 		}
 	}
 }
@@ -193,6 +333,32 @@ func (client *Client) GetDiversion(network string, prefixLength int) (Diversion,
 	return receivedDiversion, err
 }
 
+// Manually divert a network prefix such as "203.0.113.0/24", and return the new diversion made
+func (client *Client) CreateDiversion(prefix string) (Diversion, error) {
+	_, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return Diversion{}, err
+	}
+
+	prefixLength, _ := network.Mask.Size()
+	endpoint := fmt.Sprintf("%s/diversions/%s/%d", client.baseURL, network.IP, prefixLength)
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		return Diversion{}, err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	body, err := client.handleRequest(req)
+	if err != nil {
+		return Diversion{}, err
+	}
+	var createdDiversion Diversion
+	err = json.Unmarshal(body, &createdDiversion)
+
+	return createdDiversion, err
+}
+
 // Delete a network diversion
 func (client *Client) DeleteDiversion(network string, prefixLength int) error {
 	return client.deleteResource(fmt.Sprintf("/diversions/%s/%d", network, prefixLength))
@@ -288,6 +454,31 @@ func (client *Client) GetRateLimiters() (RateLimiters, error) {
 	return receivedRateLimiters, err
 }
 
+// Create a new rate limiter, and return the new rate limiter made
+func (client *Client) CreateRateLimiter(newRateLimiter RateLimiter) (RateLimiter, error) {
+	endpoint := client.baseURL + "/rate_limiters"
+
+	jsonBody, err := json.Marshal(newRateLimiter)
+	if err != nil {
+		return RateLimiter{}, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return RateLimiter{}, err
+	}
+
+	body, err := client.handleRequest(req)
+	if err != nil {
+		return RateLimiter{}, err
+	}
+
+	var createdRateLimiter RateLimiter
+	err = json.Unmarshal(body, &createdRateLimiter)
+
+	return createdRateLimiter, err
+}
+
 // Update an existing rate limiter
 func (client *Client) UpdateRateLimiter(rateLimiterID string, updatedRateLimiter RateLimiter) (RateLimiter, error) {
 	endpoint := fmt.Sprintf("%s/rate_limiters/%s", client.baseURL, rateLimiterID)
@@ -360,7 +551,7 @@ func (client *Client) GetAttackHistory() (AttackHistory, error) {
 
 // Fetch the announcement history for all hosts under your account
 func (client *Client) GetAnnouncementHistory() (AnnouncementHistory, error) {
-	endpoint := client.baseURL + "/attack_history"
+	endpoint := client.baseURL + "/announcement_history"
 	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return AnnouncementHistory{}, err
@@ -418,10 +609,72 @@ func (client *Client) GetAvailableFilters() (Filters, error) {
 	return receivedFilters, err
 }
 
-// Create a new application filter
-func (client *Client) CreateFilter(filterType string) (Filter, error) {
+// Retrieve a single application filter of the given type
+func (client *Client) GetFilter(filterType, filterID string) (Filter, error) {
+	endpoint := fmt.Sprintf("%s/filters/%s/%s", client.baseURL, filterType, filterID)
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Filter{}, err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	body, err := client.handleRequest(req)
+	if err != nil {
+		return Filter{}, err
+	}
+	var receivedFilter Filter
+	err = json.Unmarshal(body, &receivedFilter)
+
+	return receivedFilter, err
+}
+
+// Retrieve the schema of every application filter, describing the settings that each filter type accepts
+func (client *Client) GetFilterOptions() (FiltersOptions, error) {
+	endpoint := client.baseURL + "/filters/options"
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return FiltersOptions{}, err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	body, err := client.handleRequest(req)
+	if err != nil {
+		return FiltersOptions{}, err
+	}
+	var receivedOptions FiltersOptions
+	err = json.Unmarshal(body, &receivedOptions)
+
+	return receivedOptions, err
+}
+
+// Create a new application filter. The settings are sent as a JSON object of field values, as described by the
+// filter's FilterOption, and may be a FilterSettings map or a struct with JSON tags. Settings which encode to null, such
+// as nil or a nil pointer, are rejected; pass an empty FilterSettings to create the filter with its defaults
+func (client *Client) CreateFilter(filterType string, settings interface{}) (Filter, error) {
 	endpoint := fmt.Sprintf("%s/filters/%s", client.baseURL, filterType)
-	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	return client.sendFilter(endpoint, settings)
+}
+
+// Update the settings of an existing application filter. The settings are given as for CreateFilter
+func (client *Client) UpdateFilter(filterType, filterID string, settings interface{}) (Filter, error) {
+	endpoint := fmt.Sprintf("%s/filters/%s/%s", client.baseURL, filterType, filterID)
+	return client.sendFilter(endpoint, settings)
+}
+
+// sendFilter posts the settings of a filter to the endpoint and returns the resulting filter
+func (client *Client) sendFilter(endpoint string, settings interface{}) (Filter, error) {
+	jsonBody, err := json.Marshal(settings)
+	if err != nil {
+		return Filter{}, err
+	}
+
+	if bytes.Equal(jsonBody, []byte("null")) {
+		return Filter{}, errors.New("filter settings are required, use an empty FilterSettings for the filter's defaults")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return Filter{}, err
 	}
@@ -432,10 +685,10 @@ func (client *Client) CreateFilter(filterType string) (Filter, error) {
 	if err != nil {
 		return Filter{}, err
 	}
-	var createdFilter Filter
-	err = json.Unmarshal(body, &createdFilter)
+	var receivedFilter Filter
+	err = json.Unmarshal(body, &receivedFilter)
 
-	return createdFilter, err
+	return receivedFilter, err
 }
 
 // Delete a REST API service
@@ -468,14 +721,33 @@ func (client *Client) DeleteFilter(filterType, filterID string) error {
 	return client.deleteResource(fmt.Sprintf("/filters/%s/%s", filterType, filterID))
 }
 
-// Create a new Path API client and fetch an access token
-func NewClient(tokenRequest AccessTokenRequest) (Client, error) {
+// Create a new Path API client and fetch an access token. The options are applied before the token is requested. If a
+// token cache was given with WithTokenCache and holds a valid token for the same base URL and principal, that token is
+// used instead of requesting a new one
+func NewClient(tokenRequest AccessTokenRequest, options ...ClientOption) (Client, error) {
 	client := Client{
 		token:      Token{},
 		httpClient: &http.Client{},
 		baseURL:    "https://api.path.net",
 	}
 
+	for _, option := range options {
+		option(&client)
+	}
+
+	if client.tokenCache != nil {
+		key := TokenCacheKey(client.baseURL, tokenRequest)
+		token, ok, err := client.tokenCache.Load(key)
+		if err == nil && ok && token.Valid(clockOrSystem(client.clock).Now()) {
+			client.token, client.tokenKey = token, key
+			return client, nil
+		}
+	}
+
+	if client.cachedTokenOnly {
+		return client, ErrNoCachedToken
+	}
+
 	err := client.GetToken(tokenRequest)
 
 	return client, err
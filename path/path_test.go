@@ -1,11 +1,76 @@
 package path
 
-import "testing"
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
 
-// TODO:
-// 	Create a function that allows us to pass in the expected status code and response body along with the desired endpoint,
-// 	then mock Path's REST API to test the client library
+// mockAPI mocks Path's REST API. Each route maps a method and path, such as "GET /rules", to the status code and
+// response body returned for it. Requests that were received are recorded in the order they arrived, along with their
+// query strings and bodies
+type mockAPI struct {
+	routes   map[string]mockResponse
+	requests []string
+	queries  []string
+	bodies   []string
+}
+
+// mockResponse is the canned response returned for a mocked route
+type mockResponse struct {
+	status int
+	body   string
+}
+
+// newTestClient starts a mock of Path's API serving the given routes, and returns a client pointed at it
+func newTestClient(t *testing.T, routes map[string]mockResponse) (*Client, *mockAPI, func()) {
+	api := &mockAPI{routes: routes}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := fmt.Sprintf("%s %s", r.Method, r.URL.Path)
+		api.requests = append(api.requests, route)
+		api.queries = append(api.queries, r.URL.RawQuery)
+
+		body, _ := ioutil.ReadAll(r.Body)
+		api.bodies = append(api.bodies, string(body))
+
+		response, ok := api.routes[route]
+		if !ok {
+			t.Errorf("Unexpected request: %s\n", route)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(response.status)
+		fmt.Fprint(w, response.body)
+	}))
+
+	client := &Client{
+		token:      Token{AccessToken: "test", TokenType: "bearer"},
+		httpClient: server.Client(),
+		baseURL:    server.URL,
+	}
+
+	return client, api, server.Close
+}
+
+// TestGetRules ensures that rules are fetched and decoded from the /rules endpoint
+func TestGetRules(t *testing.T) {
+	client, _, closeAPI := newTestClient(t, map[string]mockResponse{
+		"GET /rules": {http.StatusOK, `{"rules":[{"id":"1","destination":"203.0.113.5/32","protocol":"tcp","dst_port":22}]}`},
+	})
+	defer closeAPI()
 
-func GetRulesTest(t *testing.T) {
+	got, err := client.GetRules()
+	if err != nil {
+		t.Fatalf("Error fetching rules: %s\n", err.Error())
+	}
 
+	expected := Rule{ID: "1", Destination: "203.0.113.5/32", Protocol: "tcp", DstPort: 22}
+	if len(got.Rules) != 1 || got.Rules[0].ID != expected.ID || got.Rules[0].DstPort != expected.DstPort {
+		t.Errorf("Expected %+v, got %+v\n", expected, got.Rules)
+	}
 }
@@ -0,0 +1,151 @@
+package path
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// parsePrefix parses either a CIDR prefix such as "203.0.113.0/24", or a single address which is treated as a host
+// prefix (/32 for IPv4 and /128 for IPv6). IPv4 prefixes always use the 4-byte representation
+func parsePrefix(prefix string) (*net.IPNet, error) {
+	prefix = strings.TrimSpace(prefix)
+
+	if strings.Contains(prefix, "/") {
+		_, network, err := net.ParseCIDR(prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		if ip4 := network.IP.To4(); ip4 != nil && len(network.Mask) == net.IPv4len {
+			network.IP = ip4
+		}
+
+		return network, nil
+	}
+
+	ip := net.ParseIP(prefix)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address or prefix: %q", prefix)
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(8*net.IPv4len, 8*net.IPv4len)}, nil
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(8*net.IPv6len, 8*net.IPv6len)}, nil
+}
+
+// prefixesOverlap reports whether two addresses or prefixes share any address. An error is only returned if the first
+// one cannot be parsed; an unparsable second one is treated as not overlapping
+func prefixesOverlap(a, b string) (bool, error) {
+	first, err := parsePrefix(a)
+	if err != nil {
+		return false, err
+	}
+
+	second, err := parsePrefix(b)
+	if err != nil {
+		return false, nil
+	}
+
+	return first.Contains(second.IP) || second.Contains(first.IP), nil
+}
+
+// prefixTree is a binary radix tree mapping IP prefixes to values. Each level of the tree consumes one bit of the
+// address, so the node for a prefix is reached after as many steps as its prefix length. IPv4 and IPv6 prefixes are
+// kept in separate trees
+type prefixTree struct {
+	v4 prefixNode
+	v6 prefixNode
+}
+
+type prefixNode struct {
+	children [2]*prefixNode
+	values   []int
+}
+
+// root returns the tree for the address family of the prefix, along with the address bytes to walk it with
+func (tree *prefixTree) root(prefix *net.IPNet) (*prefixNode, []byte) {
+	if ip4 := prefix.IP.To4(); ip4 != nil && len(prefix.Mask) == net.IPv4len {
+		return &tree.v4, ip4
+	}
+
+	return &tree.v6, prefix.IP.To16()
+}
+
+// insert stores a value under the prefix
+func (tree *prefixTree) insert(prefix *net.IPNet, value int) {
+	node, ip := tree.root(prefix)
+	ones, _ := prefix.Mask.Size()
+
+	for i := 0; i < ones; i++ {
+		bit := addressBit(ip, i)
+		if node.children[bit] == nil {
+			node.children[bit] = &prefixNode{}
+		}
+		node = node.children[bit]
+	}
+
+	node.values = append(node.values, value)
+}
+
+// insertAll stores a value under the default prefix of both address families, so that it is matched by any query
+func (tree *prefixTree) insertAll(value int) {
+	tree.v4.values = append(tree.v4.values, value)
+	tree.v6.values = append(tree.v6.values, value)
+}
+
+// containing returns the values of all prefixes which contain the given prefix, including the prefix itself
+func (tree *prefixTree) containing(prefix *net.IPNet) []int {
+	node, ip := tree.root(prefix)
+	ones, _ := prefix.Mask.Size()
+
+	values := append([]int(nil), node.values...)
+	for i := 0; i < ones; i++ {
+		node = node.children[addressBit(ip, i)]
+		if node == nil {
+			break
+		}
+		values = append(values, node.values...)
+	}
+
+	return values
+}
+
+// overlapping returns the values of all prefixes which either contain or are contained by the given prefix
+func (tree *prefixTree) overlapping(prefix *net.IPNet) []int {
+	node, ip := tree.root(prefix)
+	ones, _ := prefix.Mask.Size()
+
+	var values []int
+	for i := 0; i < ones; i++ {
+		values = append(values, node.values...)
+
+		node = node.children[addressBit(ip, i)]
+		if node == nil {
+			return values
+		}
+	}
+
+	// Every prefix below the node of the queried prefix is contained by it
+	pending := []*prefixNode{node}
+	for len(pending) > 0 {
+		node = pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		values = append(values, node.values...)
+		for _, child := range node.children {
+			if child != nil {
+				pending = append(pending, child)
+			}
+		}
+	}
+
+	return values
+}
+
+// addressBit returns the bit of the address at the given position, counting from the most significant bit
+func addressBit(ip []byte, position int) int {
+	return int(ip[position/8]>>(7-uint(position%8))) & 1
+}
@@ -0,0 +1,288 @@
+package path
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"text/template"
+	"text/template/parse"
+)
+
+// Labels identifying the protection profile that a rule or rate limiter was created for
+const (
+	profileLabel         = "profile"
+	profileInstanceLabel = "profile-instance"
+)
+
+// ProtectionProfile is a named, reusable bundle of rate limiters, rules and application filters. Its definition is a
+// JSON document which is expanded as a text/template, so that values such as {{.IP}} or {{.Port}} are filled in when
+// the profile is applied to a host:
+//
+//	{
+//		"rate_limiters": {
+//			"game": {"packets_per_second": 5000, "comment": "Game traffic"}
+//		},
+//		"rules": [
+//			{"destination": "{{.IP}}/32", "protocol": "udp", "dst_port": {{.Port}}, "rate_limiter": "game"},
+//			{"destination": "{{.IP}}/32", "comment": "Block everything else"}
+//		],
+//		"filters": [
+//			{"type": "minecraft", "settings": {"addr": "{{.IP}}/32", "port": {{.Port}}}}
+//		]
+//	}
+//
+// Rules refer to the rate limiters of the profile by their key in "rate_limiters". The value of every action is escaped
+// for use within a JSON string, so that variables cannot change the structure of the definition
+type ProtectionProfile struct {
+	Name     string
+	template *template.Template
+}
+
+// ProfileResources holds the resources of a protection profile after its definition was expanded
+type ProfileResources struct {
+	RateLimiters map[string]RateLimiter `json:"rate_limiters"`
+	Rules        []ProfileRule          `json:"rules"`
+	Filters      []ProfileFilter        `json:"filters"`
+}
+
+// ProfileRule is a rule of a protection profile
+type ProfileRule struct {
+	Rule
+	// RateLimiter is the key of the profile's rate limiter to attach the rule to
+	RateLimiter string `json:"rate_limiter,omitempty"`
+}
+
+// ProfileFilter is an application filter of a protection profile
+type ProfileFilter struct {
+	Type string `json:"type"`
+	// Settings configures the filter. If empty, the filter is created with its defaults
+	Settings FilterSettings `json:"settings,omitempty"`
+}
+
+// AppliedProfile records the resources that were created when a protection profile was applied, so that they can be
+// removed as a unit later
+type AppliedProfile struct {
+	Profile      string
+	Instance     string
+	RateLimiters []RateLimiter
+	Rules        []Rule
+	Filters      []Filter
+}
+
+// NewProtectionProfile parses the definition of a protection profile. Referencing a variable which is not provided when
+// the profile is expanded is an error
+func NewProtectionProfile(name, definition string) (*ProtectionProfile, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{
+		jsonEscapeFunc: jsonEscape,
+	}).Parse(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, defined := range tmpl.Templates() {
+		if defined.Tree != nil {
+			escapeActions(defined.Tree.Root)
+		}
+	}
+
+	return &ProtectionProfile{Name: name, template: tmpl}, nil
+}
+
+// jsonEscapeFunc is the name under which jsonEscape is available to profile definitions
+const jsonEscapeFunc = "_pathJSONEscape"
+
+// jsonEscape formats a value as the content of a JSON string. Numbers are left as they are, so that they can also be
+// written outside of strings
+func jsonEscape(value interface{}) string {
+	quoted, _ := json.Marshal(fmt.Sprint(value))
+
+	return string(quoted[1 : len(quoted)-1])
+}
+
+// escapeActions pipes the value of every action printing a value through jsonEscape, as html/template does to escape
+// HTML
+func escapeActions(node parse.Node) {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, child := range node.Nodes {
+			escapeActions(child)
+		}
+	case *parse.ActionNode:
+		// Actions declaring variables print nothing
+		if len(node.Pipe.Decl) == 0 {
+			node.Pipe.Cmds = append(node.Pipe.Cmds, &parse.CommandNode{
+				NodeType: parse.NodeCommand,
+				Args:     []parse.Node{parse.NewIdentifier(jsonEscapeFunc)},
+			})
+		}
+	case *parse.IfNode:
+		escapeActions(node.List)
+		escapeActions(node.ElseList)
+	case *parse.RangeNode:
+		escapeActions(node.List)
+		escapeActions(node.ElseList)
+	case *parse.WithNode:
+		escapeActions(node.List)
+		escapeActions(node.ElseList)
+	}
+}
+
+// Expand fills in the profile's variables and decodes the resources it defines. Rate limiters referenced by rules must
+// exist in the profile
+func (profile *ProtectionProfile) Expand(vars interface{}) (ProfileResources, error) {
+	var expanded bytes.Buffer
+	if err := profile.template.Execute(&expanded, vars); err != nil {
+		return ProfileResources{}, err
+	}
+
+	var resources ProfileResources
+	if err := json.Unmarshal(expanded.Bytes(), &resources); err != nil {
+		return ProfileResources{}, fmt.Errorf("profile %q does not expand to valid JSON: %w", profile.Name, err)
+	}
+
+	for _, rule := range resources.Rules {
+		if _, ok := resources.RateLimiters[rule.RateLimiter]; rule.RateLimiter != "" && !ok {
+			return ProfileResources{}, fmt.Errorf("profile %q references unknown rate limiter %q", profile.Name, rule.RateLimiter)
+		}
+	}
+
+	return resources, nil
+}
+
+// ApplyProfile expands the profile with the given variables and creates its rate limiters, rules and filters. The
+// instance identifies this application of the profile, e.g. the address of the host it protects, and is stored as a
+// label on every rule and rate limiter together with the profile name.
+//
+// If any resource cannot be created, the resources created so far are removed again and the error is returned. If some
+// of them cannot be removed either, the error says so as well, and they can be found again with FindAppliedProfile
+func (client *Client) ApplyProfile(profile *ProtectionProfile, instance string, vars interface{}) (AppliedProfile, error) {
+	applied := AppliedProfile{Profile: profile.Name, Instance: instance}
+
+	resources, err := profile.Expand(vars)
+	if err != nil {
+		return applied, err
+	}
+
+	rollback := func(err error) (AppliedProfile, error) {
+		err = fmt.Errorf("applying profile %q: %w", profile.Name, err)
+		if rollbackErr := client.RemoveProfile(applied); rollbackErr != nil {
+			err = fmt.Errorf("%w (removing the resources created so far failed too: %v)", err, rollbackErr)
+		}
+
+		return AppliedProfile{Profile: profile.Name, Instance: instance}, err
+	}
+
+	// Rate limiters are created in key order so that applying a profile is deterministic
+	keys := make([]string, 0, len(resources.RateLimiters))
+	for key := range resources.RateLimiters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rateLimiterIDs := map[string]string{}
+	for _, key := range keys {
+		rateLimiter := resources.RateLimiters[key]
+		rateLimiter.SetLabel(profileLabel, profile.Name)
+		rateLimiter.SetLabel(profileInstanceLabel, instance)
+
+		created, err := client.CreateRateLimiter(rateLimiter)
+		if err != nil {
+			return rollback(err)
+		}
+
+		rateLimiterIDs[key] = created.ID
+		applied.RateLimiters = append(applied.RateLimiters, created)
+	}
+
+	for _, profileRule := range resources.Rules {
+		rule := profileRule.Rule
+		if profileRule.RateLimiter != "" {
+			rateLimiterID := rateLimiterIDs[profileRule.RateLimiter]
+			rule.RateLimiterID = &rateLimiterID
+		}
+
+		rule.SetLabel(profileLabel, profile.Name)
+		rule.SetLabel(profileInstanceLabel, instance)
+
+		created, err := client.CreateRule(rule)
+		if err != nil {
+			return rollback(err)
+		}
+
+		applied.Rules = append(applied.Rules, created)
+	}
+
+	for _, filter := range resources.Filters {
+		settings := filter.Settings
+		if settings == nil {
+			settings = FilterSettings{}
+		}
+
+		created, err := client.CreateFilter(filter.Type, settings)
+		if err != nil {
+			return rollback(err)
+		}
+
+		// The filter type is needed to delete the filter again
+		if created.Name == "" {
+			created.Name = filter.Type
+		}
+
+		applied.Filters = append(applied.Filters, created)
+	}
+
+	return applied, nil
+}
+
+// FindAppliedProfile looks up the rules and rate limiters that were created for an instance of a profile using their
+// labels. Application filters cannot be labelled, so they are only known to the AppliedProfile returned by ApplyProfile
+func (client *Client) FindAppliedProfile(profile, instance string) (AppliedProfile, error) {
+	applied := AppliedProfile{Profile: profile, Instance: instance}
+	selector := Selector{
+		{Key: profileLabel, Operator: SelectorEquals, Value: profile},
+		{Key: profileInstanceLabel, Operator: SelectorEquals, Value: instance},
+	}
+
+	rules, err := client.GetRules()
+	if err != nil {
+		return applied, err
+	}
+	applied.Rules = rules.Select(selector)
+
+	rateLimiters, err := client.GetRateLimiters()
+	if err != nil {
+		return applied, err
+	}
+	applied.RateLimiters = rateLimiters.Select(selector)
+
+	return applied, nil
+}
+
+// RemoveProfile deletes the resources of an applied profile. Rules are deleted before the rate limiters they may be
+// attached to. Removal continues past failures, and the first error encountered is returned
+func (client *Client) RemoveProfile(applied AppliedProfile) error {
+	var firstErr error
+	record := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for _, rule := range applied.Rules {
+		record(client.DeleteRule(rule.ID))
+	}
+
+	for _, rateLimiter := range applied.RateLimiters {
+		record(client.DeleteRateLimiter(rateLimiter.ID))
+	}
+
+	for _, filter := range applied.Filters {
+		record(client.DeleteFilter(filter.Name, filter.ID))
+	}
+
+	return firstErr
+}
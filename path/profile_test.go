@@ -0,0 +1,108 @@
+package path
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testProfileDefinition = `{
+	"rate_limiters": {"game": {"packets_per_second": 5000, "comment": "Game traffic"}},
+	"rules": [
+		{"destination": "{{.IP}}/32", "protocol": "udp", "dst_port": {{.Port}}, "whitelist": true, "rate_limiter": "game"},
+		{"destination": "{{.IP}}/32", "comment": "Block everything else"}
+	],
+	"filters": [{"type": "minecraft"}]
+}`
+
+// TestProtectionProfileExpand ensures that variables are filled in and rate limiter references are resolved
+func TestProtectionProfileExpand(t *testing.T) {
+	profile, err := NewProtectionProfile("game-server", testProfileDefinition)
+	if err != nil {
+		t.Fatalf("Error parsing profile: %s\n", err.Error())
+	}
+
+	resources, err := profile.Expand(map[string]interface{}{"IP": "203.0.113.5", "Port": 25565})
+	if err != nil {
+		t.Fatalf("Error expanding profile: %s\n", err.Error())
+	}
+
+	expected := ProfileRule{
+		Rule:        Rule{Destination: "203.0.113.5/32", Protocol: "udp", DstPort: 25565, Whitelist: true},
+		RateLimiter: "game",
+	}
+	if len(resources.Rules) != 2 || !reflect.DeepEqual(resources.Rules[0], expected) {
+		t.Errorf("Expected %+v, got %+v\n", expected, resources.Rules)
+	}
+
+	if _, err := profile.Expand(map[string]interface{}{"IP": "203.0.113.5"}); err == nil {
+		t.Errorf("Expected an error for a missing variable\n")
+	}
+}
+
+// TestProtectionProfileEscaping ensures that variables cannot break out of the JSON strings they are written into
+func TestProtectionProfileEscaping(t *testing.T) {
+	profile, err := NewProtectionProfile("game-server", testProfileDefinition)
+	if err != nil {
+		t.Fatalf("Error parsing profile: %s\n", err.Error())
+	}
+
+	ip := `203.0.113.5/32", "whitelist": true, "comment": "\`
+	resources, err := profile.Expand(map[string]interface{}{"IP": ip, "Port": 25565})
+	if err != nil {
+		t.Fatalf("Error expanding profile: %s\n", err.Error())
+	}
+
+	if expected := ip + "/32"; resources.Rules[1].Destination != expected || resources.Rules[1].Whitelist {
+		t.Errorf("Expected %+v, got %+v\n", expected, resources.Rules[1])
+	}
+
+	if _, err := profile.Expand(map[string]interface{}{"IP": "203.0.113.5", "Port": `1, "src_port": 2`}); err == nil {
+		t.Errorf("Expected an error for a port which is not a number\n")
+	}
+}
+
+// TestApplyProfileRollback ensures that the resources created so far are removed when applying a profile fails
+func TestApplyProfileRollback(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"POST /rate_limiters":           {http.StatusOK, `{"id":"limiter","packets_per_second":5000}`},
+		"POST /rules":                   {http.StatusOK, `{"id":"rule"}`},
+		"POST /filters/minecraft":       {http.StatusInternalServerError, `{}`},
+		"DELETE /rules/rule":            {http.StatusOK, `{"acknowledged":true}`},
+		"DELETE /rate_limiters/limiter": {http.StatusOK, `{"acknowledged":true}`},
+	})
+	defer closeAPI()
+
+	profile, err := NewProtectionProfile("game-server", testProfileDefinition)
+	if err != nil {
+		t.Fatalf("Error parsing profile: %s\n", err.Error())
+	}
+
+	applied, err := client.ApplyProfile(profile, "203.0.113.5", map[string]interface{}{"IP": "203.0.113.5", "Port": 25565})
+	if err == nil {
+		t.Fatalf("Expected an error when creating the filter fails\n")
+	}
+
+	if len(applied.Rules) != 0 || len(applied.RateLimiters) != 0 {
+		t.Errorf("Expected no resources to remain applied, got %+v\n", applied)
+	}
+
+	if strings.Contains(err.Error(), "failed too") {
+		t.Errorf("Unexpected rollback failure: %v\n", err)
+	}
+
+	expectedRequests := []string{
+		"POST /rate_limiters", "POST /rules", "POST /rules", "POST /filters/minecraft",
+		"DELETE /rules/rule", "DELETE /rules/rule", "DELETE /rate_limiters/limiter",
+	}
+	if !reflect.DeepEqual(api.requests, expectedRequests) {
+		t.Errorf("Expected %v, got %v\n", expectedRequests, api.requests)
+	}
+
+	api.routes["DELETE /rate_limiters/limiter"] = mockResponse{http.StatusInternalServerError, `{}`}
+	_, err = client.ApplyProfile(profile, "203.0.113.5", map[string]interface{}{"IP": "203.0.113.5", "Port": 25565})
+	if err == nil || !strings.Contains(err.Error(), "removing the resources created so far failed too") {
+		t.Errorf("Expected the rollback failure to be reported, got %v\n", err)
+	}
+}
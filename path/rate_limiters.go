@@ -9,9 +9,16 @@ type RateLimiters struct {
 type RateLimiter struct {
 	// PacketsPerSecond is the amount of packets that can be stored in the token bucket each second before subsequent
 	// packets get rejected
-	PacketsPerSecond int `json:"packets_per_second"`
+	PacketsPerSecond PPS `json:"packets_per_second"`
 	// Comment allows you to indicate what use the rate limiter has
 	Comment string `json:"comment"`
 	// ID is a unique identifier for each rate limiter. This allows it to be attached to rules
 	ID string `json:"id,omitempty"`
 }
+
+// Admits reports whether traffic at the given rate stays within the packets per second of the rate limiter. It is the
+// comparison helper of RateLimiter, as PeakAtLeast is that of AttackDetails, so that an attack's peak can be checked
+// against a limiter without converting between rate types
+func (rateLimiter RateLimiter) Admits(rate PPS) bool {
+	return rate <= rateLimiter.PacketsPerSecond
+}
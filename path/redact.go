@@ -0,0 +1,139 @@
+package path
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// redactedSecret replaces secrets in formatted values and debug output
+const redactedSecret = "[REDACTED]"
+
+// redact hides a secret, leaving an empty one visible so that missing credentials can still be told apart
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+
+	return redactedSecret
+}
+
+// formatRedacted implements fmt.Formatter for types holding secrets. fields is a copy of the value with its secrets
+// redacted, converted to a type without methods so that it is formatted as a plain struct, and goString is used for %#v
+func formatRedacted(state fmt.State, verb rune, fields interface{}, goString string) {
+	if verb == 'v' && state.Flag('#') {
+		fmt.Fprint(state, goString)
+		return
+	}
+
+	directive := "%"
+	for _, flag := range "+-# 0" {
+		if state.Flag(int(flag)) {
+			directive += string(flag)
+		}
+	}
+	if width, ok := state.Width(); ok {
+		directive += strconv.Itoa(width)
+	}
+	if precision, ok := state.Precision(); ok {
+		directive += "." + strconv.Itoa(precision)
+	}
+
+	fmt.Fprintf(state, directive+string(verb), fields)
+}
+
+// accessTokenRequestFields formats an AccessTokenRequest without its methods
+type accessTokenRequestFields AccessTokenRequest
+
+// redacted returns a copy of the request without its password and client secret
+func (request AccessTokenRequest) redacted() AccessTokenRequest {
+	request.Password = redact(request.Password)
+	request.ClientSecret = redact(request.ClientSecret)
+
+	return request
+}
+
+// String formats the request with its password and client secret redacted
+func (request AccessTokenRequest) String() string {
+	return fmt.Sprint(request)
+}
+
+// GoString formats the request as Go syntax with its password and client secret redacted
+func (request AccessTokenRequest) GoString() string {
+	redacted := request.redacted()
+
+	return fmt.Sprintf("path.AccessTokenRequest{GrantType:%q, Username:%q, Password:%q, Scope:%q, ClientID:%q, "+
+		"ClientSecret:%q}", redacted.GrantType, redacted.Username, redacted.Password, redacted.Scope, redacted.ClientID,
+		redacted.ClientSecret)
+}
+
+// Format redacts the password and client secret whichever verb the request is formatted with
+func (request AccessTokenRequest) Format(state fmt.State, verb rune) {
+	formatRedacted(state, verb, accessTokenRequestFields(request.redacted()), request.GoString())
+}
+
+// tokenFields formats a Token without its methods
+type tokenFields Token
+
+// String formats the token with its access token redacted
+func (token Token) String() string {
+	return fmt.Sprint(token)
+}
+
+// GoString formats the token as Go syntax with its access token redacted
+func (token Token) GoString() string {
+	return fmt.Sprintf("path.Token{AccessToken:%q, TokenType:%q, ExpiresIn:%d, Expiry:%#v, Scope:%q}",
+		redact(token.AccessToken), token.TokenType, token.ExpiresIn, token.Expiry, token.Scope)
+}
+
+// Format redacts the access token whichever verb the token is formatted with
+func (token Token) Format(state fmt.State, verb rune) {
+	fields := tokenFields(token)
+	fields.AccessToken = redact(token.AccessToken)
+
+	formatRedacted(state, verb, fields, token.GoString())
+}
+
+// credentialProfileFields formats a CredentialProfile without its methods
+type credentialProfileFields CredentialProfile
+
+// redacted returns a copy of the profile without its password and client secret
+func (profile CredentialProfile) redacted() CredentialProfile {
+	profile.Password = redact(profile.Password)
+	profile.ClientSecret = redact(profile.ClientSecret)
+
+	return profile
+}
+
+// String formats the profile with its password and client secret redacted
+func (profile CredentialProfile) String() string {
+	return fmt.Sprint(profile)
+}
+
+// GoString formats the profile as Go syntax with its password and client secret redacted
+func (profile CredentialProfile) GoString() string {
+	redacted := profile.redacted()
+
+	return fmt.Sprintf("path.CredentialProfile{Name:%q, BaseURL:%q, Username:%q, Password:%q, ClientID:%q, "+
+		"ClientSecret:%q, Scope:%q, CredentialHelper:%#v}", redacted.Name, redacted.BaseURL, redacted.Username,
+		redacted.Password, redacted.ClientID, redacted.ClientSecret, redacted.Scope, redacted.CredentialHelper)
+}
+
+// Format redacts the password and client secret whichever verb the profile is formatted with
+func (profile CredentialProfile) Format(state fmt.State, verb rune) {
+	formatRedacted(state, verb, credentialProfileFields(profile.redacted()), profile.GoString())
+}
+
+// String describes the client by its base URL, leaving out its token
+func (client Client) String() string {
+	return fmt.Sprintf("path.Client{baseURL:%s, token:%s}", client.baseURL, redact(client.token.AccessToken))
+}
+
+// GoString describes the client as String does, so that %#v does not reveal its token either
+func (client Client) GoString() string {
+	return client.String()
+}
+
+// Format describes the client as String does whichever verb it is formatted with
+func (client Client) Format(state fmt.State, verb rune) {
+	fmt.Fprint(state, client.String())
+}
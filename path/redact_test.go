@@ -0,0 +1,37 @@
+package path
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestRedactSecrets ensures that secrets do not appear in formatted values, whichever verb is used
+func TestRedactSecrets(t *testing.T) {
+	request := AccessTokenRequest{Username: "foo", Password: "hunter2", ClientID: "id", ClientSecret: "s3cret"}
+	token := Token{AccessToken: "eyJhbGciOi", TokenType: "bearer"}
+	profile := CredentialProfile{Name: "production", Username: "foo", Password: "hunter2"}
+	client := Client{token: token, baseURL: "https://api.path.net"}
+
+	values := []interface{}{request, &request, token, profile, client, &client}
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%20v"} {
+		for _, value := range values {
+			formatted := fmt.Sprintf(format, value)
+			for _, secret := range []string{"hunter2", "s3cret", "eyJhbGciOi"} {
+				if strings.Contains(formatted, secret) {
+					t.Errorf("Expected %s to redact %q, got %s\n", format, secret, formatted)
+				}
+			}
+		}
+	}
+
+	expected := "{GrantType: Username:foo Password:[REDACTED] Scope: ClientID:id ClientSecret:[REDACTED]}"
+	if got := fmt.Sprintf("%+v", request); got != expected {
+		t.Errorf("Expected %+v, got %+v\n", expected, got)
+	}
+
+	// Missing secrets are left empty rather than redacted
+	if expected, got := "{    id }", (AccessTokenRequest{ClientID: "id"}).String(); got != expected {
+		t.Errorf("Expected %q, got %q\n", expected, got)
+	}
+}
@@ -0,0 +1,289 @@
+// Package report renders the attack and announcement history of a Path account for people and other tools. Histories
+// can be written as CSV, as JSON Lines, or as a Markdown or HTML summary report with per-host tables. Every writer
+// accepts Options selecting the columns to include and the time zone to show times in.
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/path-network/go-path"
+)
+
+// Column identifies a column of a report
+type Column string
+
+// Columns available for attacks. ColumnReason, ColumnStart, ColumnEnd and ColumnDuration are also available for
+// announcements
+const (
+	ColumnHost     Column = "host"
+	ColumnReason   Column = "reason"
+	ColumnVector   Column = "vector"
+	ColumnStart    Column = "start"
+	ColumnEnd      Column = "end"
+	ColumnDuration Column = "duration"
+	ColumnPeakBPS  Column = "peak_bps"
+	ColumnPeakPPS  Column = "peak_pps"
+)
+
+// Columns available for announcements only
+const (
+	ColumnNet    Column = "net"
+	ColumnActive Column = "active"
+)
+
+// DefaultAttackColumns are used for attacks when no columns are selected
+var DefaultAttackColumns = []Column{
+	ColumnHost, ColumnReason, ColumnStart, ColumnEnd, ColumnDuration, ColumnPeakBPS, ColumnPeakPPS,
+}
+
+// DefaultAnnouncementColumns are used for announcements when no columns are selected
+var DefaultAnnouncementColumns = []Column{ColumnNet, ColumnReason, ColumnStart, ColumnEnd, ColumnDuration}
+
+// Options configure how a report is rendered
+type Options struct {
+	// Title is the heading of summary reports. If empty, "Attack report" is used
+	Title string
+	// Location is the time zone in which times are shown. If nil, UTC is used
+	Location *time.Location
+	// AttackColumns and AnnouncementColumns select the columns to include, in order. If empty, the default columns are
+	// used
+	AttackColumns       []Column
+	AnnouncementColumns []Column
+	// HumanUnits writes peaks in CSV files with units, such as "412.3 Gbps", instead of as plain numbers. Summary
+	// reports always use units, and JSON Lines never do
+	HumanUnits bool
+}
+
+// location returns the configured time zone
+func (options Options) location() *time.Location {
+	if options.Location == nil {
+		return time.UTC
+	}
+
+	return options.Location
+}
+
+// attackColumns extracts the value of each column from an attack. Ongoing attacks have neither an end nor a duration
+var attackColumns = map[Column]func(path.AttackDetails) interface{}{
+	ColumnHost:   func(attack path.AttackDetails) interface{} { return attack.Host },
+	ColumnReason: func(attack path.AttackDetails) interface{} { return string(attack.Reason) },
+	ColumnVector: func(attack path.AttackDetails) interface{} { return string(attack.Reason.Vector()) },
+	ColumnStart:  func(attack path.AttackDetails) interface{} { return attack.Start.Time },
+	ColumnEnd:    func(attack path.AttackDetails) interface{} { return attack.End.Time },
+	ColumnDuration: func(attack path.AttackDetails) interface{} {
+		return duration(attack.Start.Time, attack.End.Time)
+	},
+	ColumnPeakBPS: func(attack path.AttackDetails) interface{} { return attack.PeakBPS.Value },
+	ColumnPeakPPS: func(attack path.AttackDetails) interface{} { return attack.PeakPPS.Value },
+}
+
+// announcementColumns extracts the value of each column from an announcement
+var announcementColumns = map[Column]func(path.AnnouncementDetails) interface{}{
+	ColumnNet:    func(announcement path.AnnouncementDetails) interface{} { return announcement.Net },
+	ColumnReason: func(announcement path.AnnouncementDetails) interface{} { return string(announcement.Reason) },
+	ColumnStart:  func(announcement path.AnnouncementDetails) interface{} { return announcement.Start.Time },
+	ColumnEnd:    func(announcement path.AnnouncementDetails) interface{} { return announcement.End.Time },
+	ColumnDuration: func(announcement path.AnnouncementDetails) interface{} {
+		return duration(announcement.Start.Time, announcement.End.Time)
+	},
+	ColumnActive: func(announcement path.AnnouncementDetails) interface{} { return announcement.Active() },
+}
+
+// duration returns the time between start and end, or nil if end is not known yet
+func duration(start, end time.Time) interface{} {
+	if end.IsZero() {
+		return nil
+	}
+
+	return end.Sub(start)
+}
+
+// table holds the cells of a report before they are formatted
+type table struct {
+	columns []Column
+	rows    [][]interface{}
+}
+
+// attackTable extracts the selected columns of the attacks
+func attackTable(attacks []path.AttackDetails, columns []Column) (table, error) {
+	if len(columns) == 0 {
+		columns = DefaultAttackColumns
+	}
+
+	extract := make([]func(path.AttackDetails) interface{}, len(columns))
+	for i, column := range columns {
+		if extract[i] = attackColumns[column]; extract[i] == nil {
+			return table{}, fmt.Errorf("unknown attack column %q", column)
+		}
+	}
+
+	result := table{columns: columns}
+	for _, attack := range attacks {
+		row := make([]interface{}, len(columns))
+		for i := range columns {
+			row[i] = extract[i](attack)
+		}
+		result.rows = append(result.rows, row)
+	}
+
+	return result, nil
+}
+
+// announcementTable extracts the selected columns of the announcements
+func announcementTable(announcements []path.AnnouncementDetails, columns []Column) (table, error) {
+	if len(columns) == 0 {
+		columns = DefaultAnnouncementColumns
+	}
+
+	extract := make([]func(path.AnnouncementDetails) interface{}, len(columns))
+	for i, column := range columns {
+		if extract[i] = announcementColumns[column]; extract[i] == nil {
+			return table{}, fmt.Errorf("unknown announcement column %q", column)
+		}
+	}
+
+	result := table{columns: columns}
+	for _, announcement := range announcements {
+		row := make([]interface{}, len(columns))
+		for i := range columns {
+			row[i] = extract[i](announcement)
+		}
+		result.rows = append(result.rows, row)
+	}
+
+	return result, nil
+}
+
+// WriteAttacksCSV writes the attacks as CSV with a header row. Times are written in RFC 3339 format and durations in
+// whole seconds
+func WriteAttacksCSV(w io.Writer, attacks []path.AttackDetails, options Options) error {
+	attackRows, err := attackTable(attacks, options.AttackColumns)
+	if err != nil {
+		return err
+	}
+
+	return writeCSV(w, attackRows, options)
+}
+
+// WriteAnnouncementsCSV writes the announcements as CSV with a header row. Times are written in RFC 3339 format and
+// durations in whole seconds
+func WriteAnnouncementsCSV(w io.Writer, announcements []path.AnnouncementDetails, options Options) error {
+	announcementRows, err := announcementTable(announcements, options.AnnouncementColumns)
+	if err != nil {
+		return err
+	}
+
+	return writeCSV(w, announcementRows, options)
+}
+
+// writeCSV writes a table as CSV
+func writeCSV(w io.Writer, rows table, options Options) error {
+	writer := csv.NewWriter(w)
+
+	header := make([]string, len(rows.columns))
+	for i, column := range rows.columns {
+		header[i] = string(column)
+	}
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows.rows {
+		record := make([]string, len(row))
+		for i, cell := range row {
+			record[i] = formatCSVCell(cell, options)
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// formatCSVCell formats a cell for a CSV file
+func formatCSVCell(cell interface{}, options Options) string {
+	switch value := cell.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if value.IsZero() {
+			return ""
+		}
+		return value.In(options.location()).Format(time.RFC3339)
+	case time.Duration:
+		return strconv.FormatInt(int64(value/time.Second), 10)
+	case path.BPS:
+		if options.HumanUnits {
+			return value.String()
+		}
+		return strconv.FormatUint(uint64(value), 10)
+	case path.PPS:
+		if options.HumanUnits {
+			return value.String()
+		}
+		return strconv.FormatUint(uint64(value), 10)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// WriteAttacksJSONLines writes each attack as a JSON object on its own line, keyed by column. Times are written in
+// RFC 3339 format, durations in seconds, and missing values as null
+func WriteAttacksJSONLines(w io.Writer, attacks []path.AttackDetails, options Options) error {
+	attackRows, err := attackTable(attacks, options.AttackColumns)
+	if err != nil {
+		return err
+	}
+
+	return writeJSONLines(w, attackRows, options)
+}
+
+// WriteAnnouncementsJSONLines writes each announcement as a JSON object on its own line, keyed by column. Times are
+// written in RFC 3339 format, durations in seconds, and missing values as null
+func WriteAnnouncementsJSONLines(w io.Writer, announcements []path.AnnouncementDetails, options Options) error {
+	announcementRows, err := announcementTable(announcements, options.AnnouncementColumns)
+	if err != nil {
+		return err
+	}
+
+	return writeJSONLines(w, announcementRows, options)
+}
+
+// writeJSONLines writes a table as JSON Lines
+func writeJSONLines(w io.Writer, rows table, options Options) error {
+	encoder := json.NewEncoder(w)
+
+	for _, row := range rows.rows {
+		object := make(map[Column]interface{}, len(row))
+		for i, cell := range row {
+			switch value := cell.(type) {
+			case time.Time:
+				if value.IsZero() {
+					object[rows.columns[i]] = nil
+				} else {
+					object[rows.columns[i]] = value.In(options.location()).Format(time.RFC3339)
+				}
+			case time.Duration:
+				object[rows.columns[i]] = value.Seconds()
+			default:
+				object[rows.columns[i]] = value
+			}
+		}
+
+		if err := encoder.Encode(object); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
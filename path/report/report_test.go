@@ -0,0 +1,92 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/path-network/go-path"
+)
+
+var testAttacks = []path.AttackDetails{
+	{
+		Host:    "203.0.113.5",
+		Reason:  "UDP flood",
+		Start:   path.NewTimestamp(time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)),
+		End:     path.NewTimestamp(time.Date(2020, 1, 1, 10, 30, 0, 0, time.UTC)),
+		PeakBPS: path.BPSPeak{Value: 412300000000},
+		PeakPPS: path.PPSPeak{Value: 38100000},
+	},
+	{
+		Host:    "198.51.100.7",
+		Reason:  "SYN flood | spoofed",
+		Start:   path.NewTimestamp(time.Date(2020, 1, 2, 8, 0, 0, 0, time.UTC)),
+		PeakBPS: path.BPSPeak{Value: 900},
+	},
+}
+
+// TestWriteAttacksCSV ensures that columns are selected and times shown in the configured time zone
+func TestWriteAttacksCSV(t *testing.T) {
+	var output bytes.Buffer
+	err := WriteAttacksCSV(&output, testAttacks, Options{
+		Location:      time.FixedZone("UTC+2", 2*60*60),
+		AttackColumns: []Column{ColumnHost, ColumnStart, ColumnDuration, ColumnPeakBPS},
+		HumanUnits:    true,
+	})
+	if err != nil {
+		t.Fatalf("Error writing CSV: %s\n", err.Error())
+	}
+
+	const expected = "host,start,duration,peak_bps\n" +
+		"203.0.113.5,2020-01-01T12:00:00+02:00,1800,412.3 Gbps\n" +
+		"198.51.100.7,2020-01-02T10:00:00+02:00,,900 bps\n"
+	if output.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s\n", expected, output.String())
+	}
+
+	if err := WriteAttacksCSV(&output, testAttacks, Options{AttackColumns: []Column{ColumnNet}}); err == nil {
+		t.Errorf("Expected an error for an announcement-only column\n")
+	}
+}
+
+// TestWriteAttacksJSONLines ensures that each attack is written as a JSON object on its own line
+func TestWriteAttacksJSONLines(t *testing.T) {
+	var output bytes.Buffer
+	err := WriteAttacksJSONLines(&output, testAttacks, Options{AttackColumns: []Column{ColumnHost, ColumnEnd, ColumnPeakPPS}})
+	if err != nil {
+		t.Fatalf("Error writing JSON Lines: %s\n", err.Error())
+	}
+
+	const expected = `{"end":"2020-01-01T10:30:00Z","host":"203.0.113.5","peak_pps":38100000}` + "\n" +
+		`{"end":null,"host":"198.51.100.7","peak_pps":0}` + "\n"
+	if output.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s\n", expected, output.String())
+	}
+}
+
+// TestWriteMarkdown ensures that the summary report contains a section per host with human-readable units
+func TestWriteMarkdown(t *testing.T) {
+	var output bytes.Buffer
+	if err := WriteMarkdown(&output, testAttacks, nil, Options{Title: "Weekly report"}); err != nil {
+		t.Fatalf("Error writing Markdown: %s\n", err.Error())
+	}
+
+	for _, expected := range []string{
+		"# Weekly report\n",
+		"2 attacks (1 ongoing) against 2 hosts",
+		"## 203.0.113.5\n",
+		"peaking at 412.3 Gbps and 38.1 Mpps",
+		"| Reason | Start | End | Duration | Peak BPS | Peak PPS |\n",
+		"| UDP flood | 2020-01-01 10:00:00 UTC | 2020-01-01 10:30:00 UTC | 30m0s | 412.3 Gbps | 38.1 Mpps |\n",
+		`| SYN flood \| spoofed |`,
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected report to contain %q, got:\n%s\n", expected, output.String())
+		}
+	}
+
+	if strings.Index(output.String(), "## 203.0.113.5") > strings.Index(output.String(), "## 198.51.100.7") {
+		t.Errorf("Expected hosts to be ordered by their peak, got:\n%s\n", output.String())
+	}
+}
@@ -0,0 +1,245 @@
+package report
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/path-network/go-path"
+	"github.com/path-network/go-path/analytics"
+)
+
+// summaryReport holds the formatted contents of a Markdown or HTML report
+type summaryReport struct {
+	Title         string
+	From          string
+	To            string
+	Attacks       int
+	Ongoing       int
+	Hosts         []hostSection
+	Announcements formattedTable
+}
+
+// hostSection holds the attacks against a single host
+type hostSection struct {
+	Host          string
+	Attacks       int
+	Ongoing       int
+	TotalDuration string
+	MaxPeakBPS    string
+	MaxPeakPPS    string
+	Table         formattedTable
+}
+
+// formattedTable is a table whose cells were formatted for people
+type formattedTable struct {
+	Header []string
+	Rows   [][]string
+}
+
+const markdownTemplate = `# {{.Title}}
+{{if .Attacks}}
+{{.Attacks}} attacks ({{.Ongoing}} ongoing) against {{len .Hosts}} hosts between {{.From}} and {{.To}}.
+{{else}}
+No attacks.
+{{end}}
+{{- range .Hosts}}
+## {{md .Host}}
+
+{{.Attacks}} attacks ({{.Ongoing}} ongoing), {{.TotalDuration}} in total, peaking at {{.MaxPeakBPS}} and {{.MaxPeakPPS}}.
+
+{{template "table" .Table}}
+{{- end}}
+{{- if .Announcements.Rows}}
+## Announcements
+
+{{template "table" .Announcements}}
+{{- end}}
+{{- define "table" -}}
+|{{range .Header}} {{md .}} |{{end}}
+|{{range .Header}} --- |{{end}}
+{{range .Rows}}|{{range .}} {{md .}} |{{end}}
+{{end}}{{end}}`
+
+const htmlTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Attacks}}<p>{{.Attacks}} attacks ({{.Ongoing}} ongoing) against {{len .Hosts}} hosts between {{.From}} and {{.To}}.</p>
+{{else}}<p>No attacks.</p>
+{{end}}
+{{- range .Hosts}}
+<h2>{{.Host}}</h2>
+<p>{{.Attacks}} attacks ({{.Ongoing}} ongoing), {{.TotalDuration}} in total, peaking at {{.MaxPeakBPS}} and {{.MaxPeakPPS}}.</p>
+{{template "table" .Table}}
+{{- end}}
+{{- if .Announcements.Rows}}
+<h2>Announcements</h2>
+{{template "table" .Announcements}}
+{{- end}}
+</body>
+</html>
+{{define "table"}}<table>
+<tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}`
+
+var (
+	markdownReport = template.Must(template.New("report").Funcs(template.FuncMap{"md": escapeMarkdown}).Parse(markdownTemplate))
+	htmlReport     = htmltemplate.Must(htmltemplate.New("report").Parse(htmlTemplate))
+)
+
+// WriteMarkdown writes a summary report of the attacks and announcements as Markdown. Hosts are listed by their
+// highest attack peak in bits per second, each with a table of the attacks against them
+func WriteMarkdown(w io.Writer, attacks []path.AttackDetails, announcements []path.AnnouncementDetails, options Options) error {
+	report, err := buildSummary(attacks, announcements, options)
+	if err != nil {
+		return err
+	}
+
+	return markdownReport.Execute(w, report)
+}
+
+// WriteHTML writes the same summary report as WriteMarkdown as a standalone HTML document
+func WriteHTML(w io.Writer, attacks []path.AttackDetails, announcements []path.AnnouncementDetails, options Options) error {
+	report, err := buildSummary(attacks, announcements, options)
+	if err != nil {
+		return err
+	}
+
+	return htmlReport.Execute(w, report)
+}
+
+// buildSummary aggregates and formats the contents of a summary report
+func buildSummary(attacks []path.AttackDetails, announcements []path.AnnouncementDetails, options Options) (summaryReport, error) {
+	title := options.Title
+	if title == "" {
+		title = "Attack report"
+	}
+
+	overall := analytics.Summarize(attacks)
+	report := summaryReport{
+		Title:   title,
+		From:    formatHumanCell(overall.First, options),
+		To:      formatHumanCell(overall.Last, options),
+		Attacks: overall.Count,
+		Ongoing: overall.Ongoing,
+	}
+
+	// The host is the heading of each section, so it is left out of the per-host tables
+	var columns []Column
+	for _, column := range options.AttackColumns {
+		if column != ColumnHost {
+			columns = append(columns, column)
+		}
+	}
+	if len(columns) == 0 {
+		columns = DefaultAttackColumns[1:]
+	}
+
+	attacksByHost := map[string][]path.AttackDetails{}
+	for _, attack := range attacks {
+		attacksByHost[attack.Host] = append(attacksByHost[attack.Host], attack)
+	}
+
+	summaries := analytics.ByHost(attacks)
+	for _, target := range analytics.TopByPeakBPS(attacks, -1) {
+		hostAttacks := attacksByHost[target.Host]
+		sort.SliceStable(hostAttacks, func(i, j int) bool {
+			return hostAttacks[i].Start.Before(hostAttacks[j].Start.Time)
+		})
+
+		rows, err := attackTable(hostAttacks, columns)
+		if err != nil {
+			return summaryReport{}, err
+		}
+
+		summary := summaries[target.Host]
+		report.Hosts = append(report.Hosts, hostSection{
+			Host:          target.Host,
+			Attacks:       summary.Count,
+			Ongoing:       summary.Ongoing,
+			TotalDuration: formatHumanCell(summary.TotalDuration, options),
+			MaxPeakBPS:    summary.MaxPeakBPS.String(),
+			MaxPeakPPS:    summary.MaxPeakPPS.String(),
+			Table:         formatTable(rows, options),
+		})
+	}
+
+	announcementRows, err := announcementTable(announcements, options.AnnouncementColumns)
+	if err != nil {
+		return summaryReport{}, err
+	}
+	report.Announcements = formatTable(announcementRows, options)
+
+	return report, nil
+}
+
+// formatTable formats every cell of a table for people
+func formatTable(rows table, options Options) formattedTable {
+	var formatted formattedTable
+
+	for _, column := range rows.columns {
+		formatted.Header = append(formatted.Header, columnTitle(column))
+	}
+
+	for _, row := range rows.rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = formatHumanCell(cell, options)
+		}
+		formatted.Rows = append(formatted.Rows, cells)
+	}
+
+	return formatted
+}
+
+// columnTitle turns a column name such as "peak_bps" into a heading such as "Peak BPS"
+func columnTitle(column Column) string {
+	words := strings.Split(string(column), "_")
+	for i, word := range words {
+		if word == "bps" || word == "pps" {
+			words[i] = strings.ToUpper(word)
+		} else if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// formatHumanCell formats a cell for a summary report
+func formatHumanCell(cell interface{}, options Options) string {
+	switch value := cell.(type) {
+	case nil:
+		return "-"
+	case time.Time:
+		if value.IsZero() {
+			return "-"
+		}
+		return value.In(options.location()).Format("2006-01-02 15:04:05 MST")
+	case time.Duration:
+		return value.Round(time.Second).String()
+	case bool:
+		if value {
+			return "yes"
+		}
+		return "no"
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// escapeMarkdown escapes the characters which would break up a Markdown table or start inline formatting
+func escapeMarkdown(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `|`, `\|`, `*`, `\*`, `_`, `\_`, "`", "\\`").Replace(text)
+}
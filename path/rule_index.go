@@ -0,0 +1,178 @@
+package path
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RuleIndex answers queries over a set of rules, such as "which rules affect 203.0.113.5:25565?", without scanning
+// every rule. Destinations and sources are indexed in radix trees, so that lookups by address stay fast for accounts
+// with thousands of rules. A RuleIndex is a snapshot; it must be rebuilt when the rules change
+type RuleIndex struct {
+	rules        []Rule
+	destinations prefixTree
+	sources      prefixTree
+}
+
+// RuleQuery describes the rules to look for in a RuleIndex. Zero-valued fields are not used to filter, and a rule
+// must satisfy every field that is set to be returned
+type RuleQuery struct {
+	// Destination matches rules whose destination contains this address or prefix, e.g. "203.0.113.5" or
+	// "203.0.113.0/28"
+	Destination string
+	// Source matches rules whose source overlaps this address or prefix
+	Source string
+	// Protocol matches rules for this protocol, or for any protocol. The comparison is case-insensitive
+	Protocol string
+	// DstPort matches rules for this destination port, or for any port
+	DstPort int
+	// SrcPort matches rules for this source port, or for any port
+	SrcPort int
+	// Whitelist matches rules whose whitelist flag has this value
+	Whitelist *bool
+	// RateLimiterID matches rules attached to this rate limiter
+	RateLimiterID string
+	// Comment matches rules whose comment matches this expression
+	Comment *regexp.Regexp
+}
+
+// NewRuleIndex builds an index over the given rules. Rules without a destination or source are considered to apply to
+// any address, while rules whose destination or source cannot be parsed are never matched by address queries
+func NewRuleIndex(rules []Rule) *RuleIndex {
+	index := &RuleIndex{rules: rules}
+
+	for i, rule := range rules {
+		indexRuleAddress(&index.destinations, rule.Destination, i)
+		indexRuleAddress(&index.sources, rule.Source, i)
+	}
+
+	return index
+}
+
+// indexRuleAddress stores the position of a rule under its destination or source prefix
+func indexRuleAddress(tree *prefixTree, address string, position int) {
+	if address == "" {
+		tree.insertAll(position)
+		return
+	}
+
+	prefix, err := parsePrefix(address)
+	if err != nil {
+		return
+	}
+
+	tree.insert(prefix, position)
+}
+
+// Index builds a RuleIndex over the rules
+func (rules Rules) Index() *RuleIndex {
+	return NewRuleIndex(rules.Rules)
+}
+
+// Query returns the rules matching the query in the order they were indexed. An error is returned if the destination
+// or source of the query is not a valid address or prefix
+func (index *RuleIndex) Query(query RuleQuery) ([]Rule, error) {
+	var candidates []int
+
+	if query.Destination != "" {
+		prefix, err := parsePrefix(query.Destination)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates = index.destinations.containing(prefix)
+	}
+
+	if query.Source != "" {
+		prefix, err := parsePrefix(query.Source)
+		if err != nil {
+			return nil, err
+		}
+
+		overlapping := index.sources.overlapping(prefix)
+		if query.Destination != "" {
+			candidates = intersectPositions(candidates, overlapping)
+		} else {
+			candidates = overlapping
+		}
+	}
+
+	if query.Destination == "" && query.Source == "" {
+		candidates = make([]int, len(index.rules))
+		for i := range candidates {
+			candidates[i] = i
+		}
+	}
+
+	candidates = uniquePositions(candidates)
+
+	var matches []Rule
+	for _, position := range candidates {
+		if rule := index.rules[position]; query.matches(rule) {
+			matches = append(matches, rule)
+		}
+	}
+
+	return matches, nil
+}
+
+// matches checks the fields of the query which are not answered by the radix trees
+func (query RuleQuery) matches(rule Rule) bool {
+	if query.Protocol != "" && rule.Protocol != "" && !strings.EqualFold(query.Protocol, rule.Protocol) {
+		return false
+	}
+
+	if query.DstPort != 0 && rule.DstPort != 0 && query.DstPort != rule.DstPort {
+		return false
+	}
+
+	if query.SrcPort != 0 && rule.SrcPort != 0 && query.SrcPort != rule.SrcPort {
+		return false
+	}
+
+	if query.Whitelist != nil && *query.Whitelist != rule.Whitelist {
+		return false
+	}
+
+	if query.RateLimiterID != "" && (rule.RateLimiterID == nil || *rule.RateLimiterID != query.RateLimiterID) {
+		return false
+	}
+
+	if query.Comment != nil && !query.Comment.MatchString(rule.Comment) {
+		return false
+	}
+
+	return true
+}
+
+// uniquePositions sorts rule positions and removes duplicates
+func uniquePositions(positions []int) []int {
+	sort.Ints(positions)
+
+	unique := positions[:0]
+	for i, position := range positions {
+		if i == 0 || position != positions[i-1] {
+			unique = append(unique, position)
+		}
+	}
+
+	return unique
+}
+
+// intersectPositions returns the rule positions present in both lists
+func intersectPositions(a, b []int) []int {
+	present := make(map[int]bool, len(a))
+	for _, position := range a {
+		present[position] = true
+	}
+
+	var intersection []int
+	for _, position := range b {
+		if present[position] {
+			intersection = append(intersection, position)
+		}
+	}
+
+	return intersection
+}
@@ -0,0 +1,61 @@
+package path
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// TestRuleIndexQuery ensures that rules are matched by CIDR containment, overlap, port and flags
+func TestRuleIndexQuery(t *testing.T) {
+	limiterID := "limiter"
+	index := NewRuleIndex([]Rule{
+		{ID: "host-game", Destination: "203.0.113.5/32", Protocol: "udp", DstPort: 25565},
+		{ID: "subnet", Destination: "203.0.113.0/24", Whitelist: true, Comment: "Office [team=netops]"},
+		{ID: "host-ssh", Destination: "203.0.113.5", Protocol: "tcp", DstPort: 22, RateLimiterID: &limiterID},
+		{ID: "other", Destination: "198.51.100.0/24"},
+		{ID: "source", Destination: "203.0.113.0/25", Source: "192.0.2.0/24"},
+		{ID: "v6", Destination: "2001:db8::/32"},
+		{ID: "invalid", Destination: "not-an-address"},
+	})
+
+	whitelisted := true
+	cases := []struct {
+		name     string
+		query    RuleQuery
+		expected []string
+	}{
+		{"address and port", RuleQuery{Destination: "203.0.113.5", DstPort: 25565},
+			[]string{"host-game", "subnet", "source"}},
+		{"prefix", RuleQuery{Destination: "203.0.113.0/26"}, []string{"subnet", "source"}},
+		{"source overlap", RuleQuery{Source: "192.0.2.128/25"},
+			[]string{"host-game", "subnet", "host-ssh", "other", "source", "v6", "invalid"}},
+		{"source excludes", RuleQuery{Destination: "203.0.113.5", Source: "10.0.0.0/8"},
+			[]string{"host-game", "subnet", "host-ssh"}},
+		{"protocol", RuleQuery{Destination: "203.0.113.5", Protocol: "TCP"}, []string{"subnet", "host-ssh", "source"}},
+		{"whitelist", RuleQuery{Whitelist: &whitelisted}, []string{"subnet"}},
+		{"rate limiter", RuleQuery{RateLimiterID: "limiter"}, []string{"host-ssh"}},
+		{"comment", RuleQuery{Comment: regexp.MustCompile(`team=netops`)}, []string{"subnet"}},
+		{"ipv6", RuleQuery{Destination: "2001:db8::1"}, []string{"v6"}},
+	}
+
+	for _, c := range cases {
+		rules, err := index.Query(c.query)
+		if err != nil {
+			t.Fatalf("%s: error querying rules: %s\n", c.name, err.Error())
+		}
+
+		var got []string
+		for _, rule := range rules {
+			got = append(got, rule.ID)
+		}
+
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("%s: expected %v, got %v\n", c.name, c.expected, got)
+		}
+	}
+
+	if _, err := index.Query(RuleQuery{Destination: "203.0.113.300"}); err == nil {
+		t.Errorf("Expected an error for an invalid destination\n")
+	}
+}
@@ -0,0 +1,392 @@
+package path
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheduleLabel is the label identifying which scheduled rule a firewall rule was created for
+const scheduleLabel = "schedule"
+
+// Window describes the periods during which a scheduled rule is active
+type Window interface {
+	// Contains reports whether t lies within the window
+	Contains(t time.Time) bool
+}
+
+// WeeklyWindow is a window which opens at the same time of day on a set of weekdays
+type WeeklyWindow struct {
+	// Days on which the window opens. If empty, the window opens every day
+	Days []time.Weekday
+	// Start and End are offsets from midnight, e.g. 2*time.Hour for 02:00. If End is not after Start, the window
+	// closes on the following day
+	Start time.Duration
+	End   time.Duration
+	// Location is the time zone in which Days, Start and End are interpreted. If nil, UTC is used
+	Location *time.Location
+}
+
+// Contains reports whether t lies within the window. Start and End are wall clock times, so that the window keeps
+// opening at the same time of day across daylight saving time changes
+func (window WeeklyWindow) Contains(t time.Time) bool {
+	t = t.In(locationOrUTC(window.Location))
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	// A window which wraps past midnight may have been opened on the previous day
+	for _, day := range []time.Time{midnight, midnight.AddDate(0, 0, -1)} {
+		if !window.opensOn(day.Weekday()) {
+			continue
+		}
+
+		closingDay := day
+		if window.End <= window.Start {
+			closingDay = day.AddDate(0, 0, 1)
+		}
+
+		open, closing := wallClock(day, window.Start), wallClock(closingDay, window.End)
+		if !t.Before(open) && t.Before(closing) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wallClock returns the time at the given offset from midnight of day as read on a clock in its location, rather than
+// after that much time has elapsed, which differs on days when daylight saving time starts or ends
+func wallClock(day time.Time, offset time.Duration) time.Time {
+	hours := offset / time.Hour
+	minutes := offset % time.Hour / time.Minute
+	seconds := offset % time.Minute / time.Second
+	nanoseconds := offset % time.Second
+
+	return time.Date(day.Year(), day.Month(), day.Day(), int(hours), int(minutes), int(seconds), int(nanoseconds),
+		day.Location())
+}
+
+// opensOn reports whether the window opens on the given weekday
+func (window WeeklyWindow) opensOn(weekday time.Weekday) bool {
+	if len(window.Days) == 0 {
+		return true
+	}
+
+	for _, day := range window.Days {
+		if day == weekday {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CronWindow is a window which opens at the times matched by a cron expression and stays open for a fixed duration
+type CronWindow struct {
+	schedule cronSchedule
+	duration time.Duration
+	location *time.Location
+}
+
+// NewCronWindow creates a window opening at the times matched by a standard five-field cron expression, e.g.
+// "0 2 * * 0" for 02:00 every Sunday. Fields may contain "*", numbers, ranges ("1-5"), lists ("1,3") and steps
+// ("*/15"). The expression is interpreted in the given time zone, or in UTC if location is nil
+func NewCronWindow(expression string, duration time.Duration, location *time.Location) (*CronWindow, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("cron window duration must be positive, got %s", duration)
+	}
+
+	schedule, err := parseCronSchedule(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CronWindow{schedule: schedule, duration: duration, location: locationOrUTC(location)}, nil
+}
+
+// Contains reports whether t lies within an occurrence of the window, i.e. whether the window opened less than its
+// duration before t
+func (window *CronWindow) Contains(t time.Time) bool {
+	t = t.In(window.location)
+
+	// Walk back minute by minute over the period in which an opening would still be in effect
+	earliest := t.Add(-window.duration)
+	for open := t.Truncate(time.Minute); open.After(earliest); open = open.Add(-time.Minute) {
+		if window.schedule.matches(open) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cronSchedule holds the allowed values of each field of a cron expression
+type cronSchedule struct {
+	minutes, hours, daysOfMonth, months, daysOfWeek map[int]bool
+	// Following cron, if both days of month and days of week are restricted, i.e. do not start with "*", a time matches
+	// if either of them does
+	daysOfMonthRestricted, daysOfWeekRestricted bool
+}
+
+// parseCronSchedule parses a five-field cron expression
+func parseCronSchedule(expression string) (cronSchedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return cronSchedule{}, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expression, len(fields))
+	}
+
+	var schedule cronSchedule
+	var err error
+
+	bounds := []struct {
+		field    *map[int]bool
+		min, max int
+	}{
+		{&schedule.minutes, 0, 59},
+		{&schedule.hours, 0, 23},
+		{&schedule.daysOfMonth, 1, 31},
+		{&schedule.months, 1, 12},
+		{&schedule.daysOfWeek, 0, 7},
+	}
+
+	for i, bound := range bounds {
+		*bound.field, err = parseCronField(fields[i], bound.min, bound.max)
+		if err != nil {
+			return cronSchedule{}, fmt.Errorf("invalid cron expression %q: %s", expression, err.Error())
+		}
+	}
+
+	// Both 0 and 7 represent Sunday
+	if schedule.daysOfWeek[7] {
+		schedule.daysOfWeek[0] = true
+	}
+
+	schedule.daysOfMonthRestricted = !strings.HasPrefix(fields[2], "*")
+	schedule.daysOfWeekRestricted = !strings.HasPrefix(fields[4], "*")
+
+	return schedule, nil
+}
+
+// parseCronField parses a single comma-separated cron field into the set of values it allows
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := map[int]bool{}
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		slash := strings.Index(part, "/")
+		if slash != -1 {
+			var err error
+			step, err = strconv.Atoi(part[slash+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:slash]
+		}
+
+		low, high := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+
+			var err error
+			low, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+
+			// A step after a single value runs to the end of the range, e.g. "5/15" is 5, 20, 35 and 50 for minutes
+			high = low
+			if slash != -1 {
+				high = max
+			}
+			if len(bounds) == 2 {
+				high, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q", part)
+				}
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return nil, fmt.Errorf("value %q out of range %d-%d", part, min, max)
+		}
+
+		for value := low; value <= high; value += step {
+			values[value] = true
+		}
+	}
+
+	return values, nil
+}
+
+// matches reports whether the schedule fires at the minute of t
+func (schedule cronSchedule) matches(t time.Time) bool {
+	if !schedule.minutes[t.Minute()] || !schedule.hours[t.Hour()] || !schedule.months[int(t.Month())] {
+		return false
+	}
+
+	dayOfMonth := schedule.daysOfMonth[t.Day()]
+	dayOfWeek := schedule.daysOfWeek[int(t.Weekday())]
+
+	if schedule.daysOfMonthRestricted && schedule.daysOfWeekRestricted {
+		return dayOfMonth || dayOfWeek
+	}
+
+	return dayOfMonth && dayOfWeek
+}
+
+// locationOrUTC returns the given location, falling back to UTC if none was provided
+func locationOrUTC(location *time.Location) *time.Location {
+	if location == nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+// ScheduledRule is a firewall rule which should only exist while one of its windows is open
+type ScheduledRule struct {
+	// Name identifies the scheduled rule. It is stored as a label on the firewall rule, so that the rule can be found
+	// again after a restart of the scheduler
+	Name string
+	Rule Rule
+	// The rule is active while any of its windows is open
+	Windows []Window
+	// Outside inverts the windows, so that the rule is only active while none of them are open. This allows a port to
+	// be blocked except during maintenance windows
+	Outside bool
+}
+
+// Active reports whether the rule should exist at the given time
+func (scheduled ScheduledRule) Active(t time.Time) bool {
+	open := false
+	for _, window := range scheduled.Windows {
+		if window.Contains(t) {
+			open = true
+			break
+		}
+	}
+
+	return open != scheduled.Outside
+}
+
+// Scheduler creates firewall rules when their windows open and removes them when they close. It keeps no state of its
+// own: each reconciliation compares the desired rules against the rules present on the account, so a scheduler that
+// was not running when a window opened or closed catches up on its next reconciliation
+type Scheduler struct {
+	Client *Client
+	Rules  []ScheduledRule
+	// Clock is used to determine the current time. If nil, the system clock is used
+	Clock Clock
+}
+
+// ScheduleReport describes the outcome of a single reconciliation
+type ScheduleReport struct {
+	// The time at which the reconciliation was performed
+	Time time.Time
+	// Rules that were created because their window opened
+	Created []Rule
+	// Rules that were deleted because their window closed
+	Removed []Rule
+	// Scheduled rules that could not be reconciled
+	Failed []ScheduleFailure
+}
+
+// ScheduleFailure holds the name of a scheduled rule which could not be reconciled along with the reason why
+type ScheduleFailure struct {
+	Name string
+	Err  error
+}
+
+// Reconcile creates the scheduled rules which are active and deletes the ones which are not. An active rule whose
+// definition no longer matches the scheduled rule is replaced, the new rule being created before the old one is
+// deleted. Rules labelled with a schedule name that the scheduler does not know about are left untouched. An error is
+// only returned if the rules of the account could not be listed
+func (scheduler *Scheduler) Reconcile() (ScheduleReport, error) {
+	report := ScheduleReport{Time: clockOrSystem(scheduler.Clock).Now()}
+
+	rules, err := scheduler.Client.GetRules()
+	if err != nil {
+		return report, err
+	}
+
+	existing := map[string][]Rule{}
+	for _, rule := range rules.Rules {
+		if name, ok := rule.Labels()[scheduleLabel]; ok {
+			existing[name] = append(existing[name], rule)
+		}
+	}
+
+	for _, scheduled := range scheduler.Rules {
+		current := existing[scheduled.Name]
+		active := scheduled.Active(report.Time)
+
+		newRule := scheduled.Rule
+		newRule.SetLabel(scheduleLabel, scheduled.Name)
+
+		// Keep a single rule matching the definition while active, and none otherwise
+		keep := 0
+		if active && len(current) > 0 && sameRule(current[0], newRule) {
+			keep = 1
+		}
+
+		if active && keep == 0 {
+			created, err := scheduler.Client.CreateRule(newRule)
+			if err != nil {
+				report.Failed = append(report.Failed, ScheduleFailure{Name: scheduled.Name, Err: err})
+				continue
+			}
+
+			report.Created = append(report.Created, created)
+		}
+
+		for i := keep; i < len(current); i++ {
+			if err := scheduler.Client.DeleteRule(current[i].ID); err != nil {
+				report.Failed = append(report.Failed, ScheduleFailure{Name: scheduled.Name, Err: err})
+				continue
+			}
+
+			report.Removed = append(report.Removed, current[i])
+		}
+	}
+
+	return report, nil
+}
+
+// sameRule reports whether two rules have the same definition, whatever their IDs
+func sameRule(a, b Rule) bool {
+	aLimiter, bLimiter := a.RateLimiterID, b.RateLimiterID
+	a.ID, a.RateLimiterID = "", nil
+	b.ID, b.RateLimiterID = "", nil
+
+	if aLimiter == nil || bLimiter == nil {
+		return a == b && aLimiter == bLimiter
+	}
+
+	return a == b && *aLimiter == *bLimiter
+}
+
+// Run reconciles the scheduled rules every interval until ctx is cancelled. After each reconciliation, the report and
+// any error are passed to reportFunc if it is not nil. An error is returned straight away if interval is not positive
+func (scheduler *Scheduler) Run(ctx context.Context, interval time.Duration, reportFunc func(ScheduleReport, error)) error {
+	if interval <= 0 {
+		return fmt.Errorf("schedule interval must be positive, got %s", interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := scheduler.Reconcile()
+		if reportFunc != nil {
+			reportFunc(report, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
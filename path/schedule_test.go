@@ -0,0 +1,207 @@
+package path
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestWeeklyWindow ensures that weekly windows respect their days, time zone and wrapping past midnight
+func TestWeeklyWindow(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %s\n", err.Error())
+	}
+
+	// Saturday 23:00 until Sunday 03:00, Berlin time
+	window := WeeklyWindow{Days: []time.Weekday{time.Saturday}, Start: 23 * time.Hour, End: 3 * time.Hour, Location: berlin}
+
+	cases := []struct {
+		time     time.Time
+		expected bool
+	}{
+		{time.Date(2020, 6, 6, 22, 59, 0, 0, berlin), false},
+		{time.Date(2020, 6, 6, 23, 0, 0, 0, berlin), true},
+		{time.Date(2020, 6, 7, 2, 59, 0, 0, berlin), true},
+		{time.Date(2020, 6, 7, 3, 0, 0, 0, berlin), false},
+		{time.Date(2020, 6, 6, 21, 30, 0, 0, time.UTC), true},
+		{time.Date(2020, 6, 5, 23, 30, 0, 0, berlin), false},
+	}
+
+	for _, c := range cases {
+		if got := window.Contains(c.time); got != c.expected {
+			t.Errorf("%s: expected %t, got %t\n", c.time, c.expected, got)
+		}
+	}
+}
+
+// TestWeeklyWindowDaylightSaving ensures that windows open at the same wall clock time on days when daylight saving
+// time starts or ends
+func TestWeeklyWindowDaylightSaving(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %s\n", err.Error())
+	}
+
+	// 06:00 until 08:00 every day, Berlin time. Daylight saving time started on 2020-03-29 and ended on 2020-10-25
+	window := WeeklyWindow{Start: 6 * time.Hour, End: 8 * time.Hour, Location: berlin}
+
+	cases := []struct {
+		time     time.Time
+		expected bool
+	}{
+		{time.Date(2020, 3, 29, 5, 59, 0, 0, berlin), false},
+		{time.Date(2020, 3, 29, 6, 0, 0, 0, berlin), true},
+		{time.Date(2020, 3, 29, 7, 59, 0, 0, berlin), true},
+		{time.Date(2020, 3, 29, 8, 0, 0, 0, berlin), false},
+		{time.Date(2020, 10, 25, 5, 30, 0, 0, berlin), false},
+		{time.Date(2020, 10, 25, 6, 0, 0, 0, berlin), true},
+		{time.Date(2020, 10, 25, 8, 0, 0, 0, berlin), false},
+	}
+
+	for _, c := range cases {
+		if got := window.Contains(c.time); got != c.expected {
+			t.Errorf("%s: expected %t, got %t\n", c.time, c.expected, got)
+		}
+	}
+}
+
+// TestParseCronFieldStep ensures that a step after a single value runs to the end of the range
+func TestParseCronFieldStep(t *testing.T) {
+	got, err := parseCronField("5/15", 0, 59)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	expected := map[int]bool{5: true, 20: true, 35: true, 50: true}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v\n", expected, got)
+	}
+}
+
+// TestCronWindow ensures that cron windows are open for their duration after each matching time
+func TestCronWindow(t *testing.T) {
+	// Weekdays at 02:00 and 14:00 for 30 minutes
+	window, err := NewCronWindow("0 2,14 * * 1-5", 30*time.Minute, nil)
+	if err != nil {
+		t.Fatalf("Error parsing cron window: %s\n", err.Error())
+	}
+
+	cases := []struct {
+		time     time.Time
+		expected bool
+	}{
+		{time.Date(2020, 6, 1, 2, 0, 0, 0, time.UTC), true},
+		{time.Date(2020, 6, 1, 14, 29, 59, 0, time.UTC), true},
+		{time.Date(2020, 6, 1, 14, 30, 0, 0, time.UTC), false},
+		{time.Date(2020, 6, 1, 1, 59, 0, 0, time.UTC), false},
+		{time.Date(2020, 6, 6, 2, 10, 0, 0, time.UTC), false},
+	}
+
+	for _, c := range cases {
+		if got := window.Contains(c.time); got != c.expected {
+			t.Errorf("%s: expected %t, got %t\n", c.time, c.expected, got)
+		}
+	}
+
+	// Days of month and days of week are only combined with OR when neither starts with "*", even with a step
+	stepped, err := NewCronWindow("0 2 */2 * 1", time.Hour, nil)
+	if err != nil {
+		t.Fatalf("Error parsing cron window: %s\n", err.Error())
+	}
+
+	if stepped.Contains(time.Date(2020, 6, 3, 2, 0, 0, 0, time.UTC)) ||
+		!stepped.Contains(time.Date(2020, 6, 1, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected %q to only match Mondays on odd days\n", "0 2 */2 * 1")
+	}
+
+	for _, expression := range []string{"* * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *"} {
+		if _, err := NewCronWindow(expression, time.Minute, nil); err == nil {
+			t.Errorf("Expected an error for %q\n", expression)
+		}
+	}
+}
+
+// TestSchedulerReconcile ensures that the scheduler creates open rules and removes closed ones it finds on the account
+func TestSchedulerReconcile(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"GET /rules": {http.StatusOK, `{"rules":[
+			{"id":"1","comment":"Block SSH [schedule=block-ssh]"},
+			{"id":"2","comment":"Unrelated"}
+		]}`},
+		"POST /rules":     {http.StatusOK, `{"id":"3","comment":"Allow SSH [schedule=allow-ssh]"}`},
+		"DELETE /rules/1": {http.StatusOK, `{"acknowledged":true}`},
+	})
+	defer closeAPI()
+
+	maintenance := WeeklyWindow{Days: []time.Weekday{time.Sunday}, Start: 2 * time.Hour, End: 4 * time.Hour}
+	scheduler := Scheduler{
+		Client: client,
+		Rules: []ScheduledRule{
+			{Name: "allow-ssh", Rule: Rule{DstPort: 22, Whitelist: true}, Windows: []Window{maintenance}},
+			{Name: "block-ssh", Rule: Rule{DstPort: 22}, Windows: []Window{maintenance}, Outside: true},
+		},
+		Clock: fixedClock(time.Date(2020, 6, 7, 3, 0, 0, 0, time.UTC)),
+	}
+
+	report, err := scheduler.Reconcile()
+	if err != nil {
+		t.Fatalf("Error reconciling scheduled rules: %s\n", err.Error())
+	}
+
+	if len(report.Created) != 1 || len(report.Removed) != 1 || len(report.Failed) != 0 {
+		t.Errorf("Expected one rule to be created and one removed, got %+v\n", report)
+	}
+
+	expectedRequests := []string{"GET /rules", "POST /rules", "DELETE /rules/1"}
+	if !reflect.DeepEqual(api.requests, expectedRequests) {
+		t.Errorf("Expected %v, got %v\n", expectedRequests, api.requests)
+	}
+}
+
+// TestSchedulerReconcileChangedRule ensures that an active rule whose definition changed is replaced
+func TestSchedulerReconcileChangedRule(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"GET /rules": {http.StatusOK, `{"rules":[
+			{"id":"1","dst_port":22,"whitelist":true,"comment":"[schedule=allow-ssh]"},
+			{"id":"2","dst_port":80,"comment":"[schedule=block-web]"}
+		]}`},
+		"POST /rules":     {http.StatusOK, `{"id":"3","dst_port":8080,"comment":"[schedule=block-web]"}`},
+		"DELETE /rules/2": {http.StatusOK, `{"acknowledged":true}`},
+	})
+	defer closeAPI()
+
+	sunday := WeeklyWindow{Days: []time.Weekday{time.Sunday}}
+	scheduler := Scheduler{
+		Client: client,
+		Rules: []ScheduledRule{
+			{Name: "allow-ssh", Rule: Rule{DstPort: 22, Whitelist: true}, Windows: []Window{sunday}, Outside: true},
+			{Name: "block-web", Rule: Rule{DstPort: 8080}, Windows: []Window{sunday}, Outside: true},
+		},
+		Clock: fixedClock(time.Date(2020, 6, 8, 3, 0, 0, 0, time.UTC)),
+	}
+
+	report, err := scheduler.Reconcile()
+	if err != nil {
+		t.Fatalf("Error reconciling scheduled rules: %s\n", err.Error())
+	}
+
+	if len(report.Created) != 1 || len(report.Removed) != 1 || report.Removed[0].ID != "2" {
+		t.Errorf("Expected the changed rule to be replaced, got %+v\n", report)
+	}
+
+	expectedRequests := []string{"GET /rules", "POST /rules", "DELETE /rules/2"}
+	if !reflect.DeepEqual(api.requests, expectedRequests) {
+		t.Errorf("Expected %v, got %v\n", expectedRequests, api.requests)
+	}
+}
+
+// TestSchedulerRunInterval ensures that a non-positive interval is rejected instead of panicking
+func TestSchedulerRunInterval(t *testing.T) {
+	scheduler := Scheduler{}
+	if err := scheduler.Run(context.Background(), 0, nil); err == nil {
+		t.Errorf("Expected an error for a zero interval\n")
+	}
+}
@@ -0,0 +1,26 @@
+//go:build go1.21
+// +build go1.21
+
+package path
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// SlogLogger adapts a structured logger into a Logger for WithLogger, logging every line at the given level
+func SlogLogger(logger *slog.Logger, level slog.Level) Logger {
+	return slogLogger{logger: logger, level: level}
+}
+
+// slogLogger is the Logger returned by SlogLogger
+type slogLogger struct {
+	logger *slog.Logger
+	level  slog.Level
+}
+
+// Printf formats the line and logs it as the message of a record
+func (logger slogLogger) Printf(format string, args ...interface{}) {
+	logger.logger.Log(context.Background(), logger.level, fmt.Sprintf(format, args...))
+}
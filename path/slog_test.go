@@ -0,0 +1,23 @@
+//go:build go1.21
+// +build go1.21
+
+package path
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestSlogLogger ensures that lines are logged as records of the given level
+func TestSlogLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := SlogLogger(slog.New(slog.NewTextHandler(&out, nil)), slog.LevelWarn)
+
+	logger.Printf("path: %s %s: %s", "GET", "/rules", "200 OK")
+
+	if expected := `level=WARN msg="path: GET /rules: 200 OK"`; !strings.Contains(out.String(), expected) {
+		t.Errorf("Expected %+v, got %+v\n", expected, out.String())
+	}
+}
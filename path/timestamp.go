@@ -0,0 +1,83 @@
+package path
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timestamp is a point in time returned by Path's API. The API is not consistent in how it formats times, so a
+// Timestamp accepts RFC 3339 times with or without fractional seconds and time zone, times separated by a space instead
+// of a "T", and Unix times in seconds given as a number or a string. Times without a zone are taken to be in UTC. A
+// null or empty value leaves the zero time, which can be checked with IsZero
+type Timestamp struct {
+	time.Time
+}
+
+// timestampLayouts are the layouts tried in order when parsing a timestamp
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// NewTimestamp wraps a time in a Timestamp
+func NewTimestamp(t time.Time) Timestamp {
+	return Timestamp{Time: t}
+}
+
+// ParseTimestamp parses a timestamp in any of the formats returned by the API
+func ParseTimestamp(value string) (Timestamp, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "null" {
+		return Timestamp{}, nil
+	}
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return Timestamp{Time: t}, nil
+		}
+	}
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		whole := int64(seconds)
+		return Timestamp{Time: time.Unix(whole, int64((seconds-float64(whole))*1e9)).UTC()}, nil
+	}
+
+	return Timestamp{}, fmt.Errorf("unrecognized timestamp %q", value)
+}
+
+// UnmarshalJSON decodes a timestamp from a JSON string, number or null
+func (timestamp *Timestamp) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	value := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := ParseTimestamp(value)
+	if err != nil {
+		return err
+	}
+
+	*timestamp = parsed
+
+	return nil
+}
+
+// MarshalJSON encodes the timestamp as an RFC 3339 string, or as null if it is the zero time
+func (timestamp Timestamp) MarshalJSON() ([]byte, error) {
+	if timestamp.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return timestamp.Time.MarshalJSON()
+}
@@ -0,0 +1,44 @@
+package path
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestTimestamp ensures that every timestamp format returned by the API is decoded
+func TestTimestamp(t *testing.T) {
+	expected := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	cases := []string{
+		`"2020-01-02T15:04:05Z"`,
+		`"2020-01-02T17:04:05+02:00"`,
+		`"2020-01-02T15:04:05"`,
+		`"2020-01-02 15:04:05"`,
+		`"2020-01-02T15:04:05.000000"`,
+		`1577977445`,
+		`"1577977445"`,
+	}
+
+	for _, c := range cases {
+		var got Timestamp
+		if err := json.Unmarshal([]byte(c), &got); err != nil {
+			t.Errorf("%s: error unmarshalling timestamp: %s\n", c, err.Error())
+			continue
+		}
+
+		if !got.Equal(expected) {
+			t.Errorf("%s: expected %s, got %s\n", c, expected, got)
+		}
+	}
+
+	var details UnderAttack
+	if err := json.Unmarshal([]byte(`{"host":"203.0.113.5","since":null}`), &details); err != nil || !details.Since.IsZero() {
+		t.Errorf("Expected a zero timestamp for null, got %s (error: %v)\n", details.Since, err)
+	}
+
+	var invalid Timestamp
+	if err := json.Unmarshal([]byte(`"yesterday"`), &invalid); err == nil {
+		t.Errorf("Expected an error for an unrecognized timestamp\n")
+	}
+}
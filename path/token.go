@@ -1,20 +1,116 @@
 package path
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Grant types accepted by the /token endpoint
+const (
+	// GrantPassword authenticates with the username and password of an account
+	GrantPassword = "password"
+	// GrantClientCredentials authenticates with a client ID and secret, without an account password
+	GrantClientCredentials = "client_credentials"
+)
+
+// ErrInvalidTokenRequest is wrapped by the errors of AccessTokenRequest.Validate
+var ErrInvalidTokenRequest = errors.New("invalid token request")
+
 // Token holds the data returned from the /token endpoint after successful authentication
 type Token struct {
 	AccessToken string `json:"access_token"`
 	TokenType   string `json:"token_type"`
+	// ExpiresIn is the lifetime of the token in seconds, as returned by the API
+	ExpiresIn int `json:"expires_in,omitempty"`
+	// Expiry is the time at which the token expires, derived from ExpiresIn when the token is received. It is the zero
+	// time if the API did not return a lifetime
+	Expiry time.Time `json:"expiry,omitempty"`
+	// Scope holds the space-separated scopes granted. If the API does not return them, they are those requested
+	Scope string `json:"scope,omitempty"`
+}
+
+// tokenExpiryLeeway is how long before its expiry a token stops being reused, so that it does not expire in flight
+const tokenExpiryLeeway = 30 * time.Second
+
+// Valid reports whether the token is known to remain usable for a while at the given time. Tokens without an expiry
+// are never considered valid, since they may have expired at any point
+func (token Token) Valid(now time.Time) bool {
+	return token.AccessToken != "" && !token.Expiry.IsZero() && now.Add(tokenExpiryLeeway).Before(token.Expiry)
+}
+
+// Scopes returns the scopes granted to the token
+func (token Token) Scopes() []string {
+	return strings.Fields(token.Scope)
+}
+
+// HasScope reports whether the given scope was granted to the token
+func (token Token) HasScope(scope string) bool {
+	for _, granted := range token.Scopes() {
+		if granted == scope {
+			return true
+		}
+	}
+
+	return false
 }
 
 // AccessTokenRequest holds the necessary data that the /token endpoint expects
 type AccessTokenRequest struct {
+	// GrantType is GrantPassword or GrantClientCredentials. If empty, it is inferred from the credentials given
 	GrantType    string `json:"grant_type"`
 
 	// Required
 	Username     string `json:"username"`
 	Password     string `json:"password"`
 
+	// Scope holds the space-separated scopes requested
 	Scope        string `json:"scope"`
 	ClientID     string `json:"client_id"`
 	ClientSecret string `json:"client_secret"`
 }
+
+// EffectiveGrantType returns the grant type of the request. Unless GrantType is set, requests holding a username use
+// GrantPassword and the others GrantClientCredentials
+func (request AccessTokenRequest) EffectiveGrantType() string {
+	switch {
+	case request.GrantType != "":
+		return request.GrantType
+	case request.Username != "":
+		return GrantPassword
+	default:
+		return GrantClientCredentials
+	}
+}
+
+// Validate checks that the request holds the credentials needed by its grant type: a username and password for
+// GrantPassword, along with an optional client ID and secret, or a client ID and secret alone for
+// GrantClientCredentials
+func (request AccessTokenRequest) Validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidTokenRequest, fmt.Sprintf(format, args...))
+	}
+
+	if request.ClientSecret != "" && request.ClientID == "" {
+		return invalid("a client secret requires a client ID")
+	}
+
+	switch grantType := request.EffectiveGrantType(); grantType {
+	case GrantPassword:
+		if request.Username == "" || request.Password == "" {
+			return invalid("the %s grant requires a username and password", grantType)
+		}
+	case GrantClientCredentials:
+		if request.ClientID == "" || request.ClientSecret == "" {
+			return invalid("the %s grant requires a client ID and client secret", grantType)
+		}
+		if request.Username != "" || request.Password != "" {
+			return invalid("the %s grant does not accept a username or password", grantType)
+		}
+	default:
+		return invalid("unsupported grant type %q", grantType)
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package path
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TokenCache stores access tokens between clients, and between processes for persistent implementations, so that the
+// /token endpoint does not have to be called every time a client is created
+type TokenCache interface {
+	// Load returns the token stored under the key. The second return value is false if there is none
+	Load(key string) (Token, bool, error)
+	// Store saves the token under the key, replacing any previous token
+	Store(key string, token Token) error
+}
+
+// TokenCacheDeleter is implemented by token caches which can remove a token, such as FileTokenCache. Client.Logout
+// removes the client's token from caches implementing it
+type TokenCacheDeleter interface {
+	// Delete removes the token stored under the key, if there is one
+	Delete(key string) error
+}
+
+// ErrNoCachedToken is returned by NewClient, when created with WithCachedTokenOnly, if the token cache holds no valid
+// token for the client
+var ErrNoCachedToken = errors.New("no valid token in the token cache")
+
+// TokenCacheKey returns the key under which the token of a principal is cached. Tokens are kept apart by base URL,
+// principal (the username, or the client ID if there is none) and requested scope
+func TokenCacheKey(baseURL string, request AccessTokenRequest) string {
+	principal := request.Username
+	if principal == "" {
+		principal = "client:" + request.ClientID
+	}
+
+	return strings.Join([]string{strings.TrimRight(baseURL, "/"), principal, request.Scope}, " ")
+}
+
+// DefaultTokenCachePath returns the location of the token cache within the user's cache directory, such as
+// ~/.cache/path/tokens.json on Linux
+func DefaultTokenCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "path", "tokens.json"), nil
+}
+
+// FileTokenCache stores tokens as JSON in a file which only its owner can read. Access to the file is serialized with
+// a lock file next to it, so that concurrent processes sharing the cache do not lose each other's tokens
+type FileTokenCache struct {
+	Path string
+}
+
+// Load returns the token stored under the key. A missing file holds no tokens
+func (cache FileTokenCache) Load(key string) (Token, bool, error) {
+	unlock, err := cache.lock()
+	if err != nil {
+		return Token{}, false, err
+	}
+	defer unlock()
+
+	tokens, err := cache.read()
+	if err != nil {
+		return Token{}, false, err
+	}
+
+	token, ok := tokens[key]
+
+	return token, ok, nil
+}
+
+// Store saves the token under the key. Expired tokens of other keys are dropped from the file at the same time
+func (cache FileTokenCache) Store(key string, token Token) error {
+	unlock, err := cache.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tokens, err := cache.read()
+	if err != nil {
+		return err
+	}
+
+	for storedKey, storedToken := range tokens {
+		if !storedToken.Expiry.IsZero() && !storedToken.Valid(time.Now()) {
+			delete(tokens, storedKey)
+		}
+	}
+	tokens[key] = token
+
+	return cache.write(tokens)
+}
+
+// Delete removes the token stored under the key. A missing file holds no tokens, so there is nothing to delete
+func (cache FileTokenCache) Delete(key string) error {
+	unlock, err := cache.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tokens, err := cache.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := tokens[key]; !ok {
+		return nil
+	}
+	delete(tokens, key)
+
+	return cache.write(tokens)
+}
+
+// lock serializes access to the file with the lock file next to it, creating their directory if needed
+func (cache FileTokenCache) lock() (func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(cache.Path), 0700); err != nil {
+		return nil, err
+	}
+
+	return lockFile(cache.Path + ".lock")
+}
+
+// read decodes the tokens of the file, which must be locked
+func (cache FileTokenCache) read() (map[string]Token, error) {
+	tokens := map[string]Token{}
+
+	data, err := ioutil.ReadFile(cache.Path)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &tokens)
+
+	return tokens, err
+}
+
+// write replaces the file, which must be locked, through a temporary file so that readers never see a partial write.
+// Temporary files are created with 0600 permissions
+func (cache FileTokenCache) write(tokens map[string]Token) error {
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(cache.Path), filepath.Base(cache.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), cache.Path)
+}
@@ -0,0 +1,49 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package path
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Timings of the lock files used where advisory locks are not available
+const (
+	// lockTimeout is how long lockFile waits for another process to release the lock
+	lockTimeout = 10 * time.Second
+	// staleLockAge is the age after which a lock file is assumed to have been left behind by a process that exited
+	staleLockAge = time.Minute
+)
+
+// lockFile takes a lock by creating the file exclusively, and returns the function releasing it by removing the file.
+// A lock file older than staleLockAge is removed, since the process holding it is assumed to have exited
+func lockFile(filename string) (func() error, error) {
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		file, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return func() error {
+				return os.Remove(filename)
+			}, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(filename); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(filename)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for the lock %s", filename)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
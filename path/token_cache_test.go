@@ -0,0 +1,125 @@
+package path
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestFileTokenCache ensures that tokens are stored privately, survive concurrent writers and are dropped once expired
+func TestFileTokenCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tokens")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache := FileTokenCache{Path: filepath.Join(dir, "path", "tokens.json")}
+	if err := cache.Store("expired", Token{AccessToken: "old", Expiry: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	var wait sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+
+			token := Token{AccessToken: fmt.Sprint(i), Expiry: time.Now().Add(time.Hour)}
+			if err := (FileTokenCache{Path: cache.Path}).Store(fmt.Sprint(i), token); err != nil {
+				t.Errorf("Unexpected error: %v\n", err)
+			}
+		}(i)
+	}
+	wait.Wait()
+
+	for i := 0; i < 20; i++ {
+		token, ok, err := cache.Load(fmt.Sprint(i))
+		if err != nil || !ok || token.AccessToken != fmt.Sprint(i) {
+			t.Errorf("Expected token %d, got %+v (%v, %v)\n", i, token, ok, err)
+		}
+	}
+
+	if _, ok, _ := cache.Load("expired"); ok {
+		t.Errorf("Expected the expired token to be dropped\n")
+	}
+
+	info, err := os.Stat(cache.Path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected permissions %v, got %+v (%v)\n", os.FileMode(0600), info, err)
+	}
+}
+
+// TestNewClientTokenCache ensures that NewClient reuses a valid cached token rather than authenticating again
+func TestNewClientTokenCache(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"POST /token": {http.StatusOK, `{"access_token": "cached", "token_type": "bearer", "expires_in": 3600}`},
+	})
+	defer closeAPI()
+
+	dir, err := ioutil.TempDir("", "tokens")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	options := []ClientOption{
+		WithBaseURL(client.baseURL), WithHTTPClient(client.httpClient),
+		WithTokenCache(FileTokenCache{Path: filepath.Join(dir, "tokens.json")}),
+	}
+
+	for i := 0; i < 3; i++ {
+		cached, err := NewClient(AccessTokenRequest{Username: "foo", Password: "bar"}, options...)
+		if err != nil || cached.token.AccessToken != "cached" {
+			t.Fatalf("Unexpected client: %+v (%v)\n", cached.token, err)
+		}
+	}
+
+	if _, err := NewClient(AccessTokenRequest{Username: "baz", Password: "bar"}, options...); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	// The first client and the one for another principal authenticate, while the others reuse the cached token
+	if len(api.requests) != 2 {
+		t.Errorf("Expected %+v, got %+v\n", 2, api.requests)
+	}
+}
+
+// TestNewClientTokenCacheClock ensures that the expiry of tokens is computed and checked with the client's clock
+func TestNewClientTokenCacheClock(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"POST /token": {http.StatusOK, `{"access_token": "cached", "token_type": "bearer", "expires_in": 3600}`},
+	})
+	defer closeAPI()
+
+	dir, err := ioutil.TempDir("", "tokens")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	options := []ClientOption{
+		WithBaseURL(client.baseURL), WithHTTPClient(client.httpClient), WithClock(fixedClock(now)),
+		WithTokenCache(FileTokenCache{Path: filepath.Join(dir, "tokens.json")}),
+	}
+
+	first, err := NewClient(AccessTokenRequest{Username: "foo", Password: "bar"}, options...)
+	if expected := now.Add(time.Hour); err != nil || !first.token.Expiry.Equal(expected) {
+		t.Fatalf("Expected a token expiring at %s, got %+v (%v)\n", expected, first.token, err)
+	}
+
+	// The token expired long ago by the system clock, but not by the client's
+	if _, err := NewClient(AccessTokenRequest{Username: "foo", Password: "bar"}, options...); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	if len(api.requests) != 1 {
+		t.Errorf("Expected %+v, got %+v\n", 1, api.requests)
+	}
+}
@@ -0,0 +1,121 @@
+package path
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestAccessTokenRequestValidate ensures that the credentials required by each grant type are checked
+func TestAccessTokenRequestValidate(t *testing.T) {
+	cases := []struct {
+		request   AccessTokenRequest
+		grantType string
+		valid     bool
+	}{
+		{AccessTokenRequest{Username: "foo", Password: "bar"}, GrantPassword, true},
+		{AccessTokenRequest{Username: "foo", Password: "bar", ClientID: "id", ClientSecret: "secret"}, GrantPassword, true},
+		{AccessTokenRequest{Username: "foo"}, GrantPassword, false},
+		{AccessTokenRequest{ClientID: "id", ClientSecret: "secret"}, GrantClientCredentials, true},
+		{AccessTokenRequest{ClientID: "id"}, GrantClientCredentials, false},
+		{AccessTokenRequest{ClientSecret: "secret"}, GrantClientCredentials, false},
+		{AccessTokenRequest{GrantType: GrantClientCredentials, Username: "foo", ClientID: "id", ClientSecret: "secret"},
+			GrantClientCredentials, false},
+		{AccessTokenRequest{GrantType: "implicit", ClientID: "id"}, "implicit", false},
+	}
+
+	for _, c := range cases {
+		if got := c.request.EffectiveGrantType(); got != c.grantType {
+			t.Errorf("Expected %+v, got %+v\n", c.grantType, got)
+		}
+
+		err := c.request.Validate()
+		if (err == nil) != c.valid || (err != nil && !errors.Is(err, ErrInvalidTokenRequest)) {
+			t.Errorf("Unexpected validation of %+v: %v\n", c.request.GrantType, err)
+		}
+	}
+}
+
+// TestGetTokenClientCredentials ensures that only the fields of the grant are sent, and that the granted scopes are
+// surfaced on the token
+func TestGetTokenClientCredentials(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"POST /token": {http.StatusOK, `{"access_token": "test", "token_type": "bearer", "scope": "rules:read"}`},
+	})
+	defer closeAPI()
+
+	request := AccessTokenRequest{ClientID: "id", ClientSecret: "secret", Scope: "rules:read rules:write"}
+	if err := client.GetToken(request); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	form, err := url.ParseQuery(api.bodies[0])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	expected := url.Values{
+		"grant_type": {GrantClientCredentials}, "client_id": {"id"}, "client_secret": {"secret"},
+		"scope": {"rules:read rules:write"},
+	}
+	if form.Encode() != expected.Encode() {
+		t.Errorf("Expected %+v, got %+v\n", expected.Encode(), form.Encode())
+	}
+
+	if token := client.Token(); !token.HasScope("rules:read") || token.HasScope("rules:write") {
+		t.Errorf("Unexpected scopes: %+v\n", token.Scopes())
+	}
+
+	if err := client.GetToken(AccessTokenRequest{ClientID: "id"}); !errors.Is(err, ErrInvalidTokenRequest) {
+		t.Errorf("Expected %v, got %v\n", ErrInvalidTokenRequest, err)
+	}
+
+	if len(api.requests) != 1 {
+		t.Errorf("Expected invalid requests not to be sent, got %+v\n", api.requests)
+	}
+}
+
+// TestLogout ensures that logging out revokes the token and removes it from the token cache
+func TestLogout(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"POST /token":        {http.StatusOK, `{"access_token": "test", "token_type": "bearer", "expires_in": 3600}`},
+		"POST /token/revoke": {http.StatusOK, `{}`},
+	})
+	defer closeAPI()
+
+	dir, err := ioutil.TempDir("", "tokens")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache := FileTokenCache{Path: filepath.Join(dir, "tokens.json")}
+	request := AccessTokenRequest{Username: "foo", Password: "bar"}
+	loggedIn, err := NewClient(request, WithBaseURL(client.baseURL), WithHTTPClient(client.httpClient),
+		WithTokenCache(cache))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	if err := loggedIn.Logout(); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	if expected := "token=test&token_type_hint=access_token"; api.bodies[1] != expected {
+		t.Errorf("Expected %+v, got %+v\n", expected, api.bodies[1])
+	}
+
+	if _, ok, _ := cache.Load(TokenCacheKey(client.baseURL, request)); ok || loggedIn.Token().AccessToken != "" {
+		t.Errorf("Expected the token to be forgotten\n")
+	}
+
+	_, err = NewClient(request, WithBaseURL(client.baseURL), WithHTTPClient(client.httpClient), WithTokenCache(cache),
+		WithCachedTokenOnly())
+	if !errors.Is(err, ErrNoCachedToken) || len(api.requests) != 2 {
+		t.Errorf("Expected %v without authenticating, got %v after %+v\n", ErrNoCachedToken, err, api.requests)
+	}
+}
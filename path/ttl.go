@@ -0,0 +1,126 @@
+package path
+
+import (
+	"context"
+	"time"
+)
+
+// expiresLabel is the label holding the time after which a rule may be removed by a RuleSweeper
+const expiresLabel = "expires"
+
+// Expiry returns the time at which the rule expires. The second return value is false if the rule has no expiry or if
+// its expiry label could not be parsed
+func (rule Rule) Expiry() (time.Time, bool) {
+	value, ok := rule.Labels()[expiresLabel]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	expiry, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return expiry, true
+}
+
+// SetExpiry stores the expiry of the rule in its comment, leaving the human-readable part of the comment untouched.
+// Passing the zero time removes the expiry
+func (rule *Rule) SetExpiry(expiry time.Time) {
+	if expiry.IsZero() {
+		rule.DeleteLabel(expiresLabel)
+		return
+	}
+
+	rule.SetLabel(expiresLabel, expiry.UTC().Format(time.RFC3339))
+}
+
+// Expired reports whether the rule has an expiry which lies more than gracePeriod before now
+func (rule Rule) Expired(now time.Time, gracePeriod time.Duration) bool {
+	expiry, ok := rule.Expiry()
+	if !ok {
+		return false
+	}
+
+	return now.After(expiry.Add(gracePeriod))
+}
+
+// CreateRuleWithTTL creates a new firewall rule which expires once ttl has elapsed. The expiry is stored in the rule's
+// comment, so that any process running a RuleSweeper against the account is able to remove it. The current time is
+// taken from the clock set with WithClock, if any
+func (client *Client) CreateRuleWithTTL(newRule Rule, ttl time.Duration) (Rule, error) {
+	newRule.SetExpiry(clockOrSystem(client.clock).Now().Add(ttl))
+
+	return client.CreateRule(newRule)
+}
+
+// RuleSweeper removes rules from an account once their expiry has passed
+type RuleSweeper struct {
+	Client *Client
+	// GracePeriod is how long a rule is kept after it has expired before it is removed
+	GracePeriod time.Duration
+	// Clock is used to determine the current time. If nil, the system clock is used
+	Clock Clock
+}
+
+// SweepReport describes the outcome of a single sweep
+type SweepReport struct {
+	// The time at which the sweep was performed
+	Time time.Time
+	// Rules that were found to be expired and have been deleted
+	Removed []Rule
+	// Rules that were found to be expired but could not be deleted
+	Failed []SweepFailure
+}
+
+// SweepFailure holds an expired rule which could not be deleted along with the reason why
+type SweepFailure struct {
+	Rule Rule
+	Err  error
+}
+
+// Sweep lists all rules of the account and deletes the ones that have expired. An error is only returned if the rules
+// could not be listed; failed deletions are recorded in the report so that the remaining rules are still swept
+func (sweeper *RuleSweeper) Sweep() (SweepReport, error) {
+	report := SweepReport{Time: clockOrSystem(sweeper.Clock).Now()}
+
+	rules, err := sweeper.Client.GetRules()
+	if err != nil {
+		return report, err
+	}
+
+	for _, rule := range rules.Rules {
+		if !rule.Expired(report.Time, sweeper.GracePeriod) {
+			continue
+		}
+
+		if err := sweeper.Client.DeleteRule(rule.ID); err != nil {
+			report.Failed = append(report.Failed, SweepFailure{Rule: rule, Err: err})
+			continue
+		}
+
+		report.Removed = append(report.Removed, rule)
+	}
+
+	return report, nil
+}
+
+// Run sweeps the account every interval until ctx is cancelled. After each sweep, the report and any error are passed
+// to reportFunc if it is not nil
+func (sweeper *RuleSweeper) Run(ctx context.Context, interval time.Duration, reportFunc func(SweepReport, error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := sweeper.Sweep()
+		if reportFunc != nil {
+			reportFunc(report, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package path
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixedClock is a Clock which always returns the same time
+type fixedClock time.Time
+
+func (clock fixedClock) Now() time.Time {
+	return time.Time(clock)
+}
+
+// TestRuleExpiry ensures that expiries survive a round trip through the rule's comment
+func TestRuleExpiry(t *testing.T) {
+	expiry := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	rule := Rule{Comment: "Emergency block, see [INC-42]"}
+	rule.SetExpiry(expiry)
+
+	const expectedComment = "Emergency block, see [INC-42] [expires=2020-01-02T15:04:05Z]"
+	if rule.Comment != expectedComment {
+		t.Errorf("Expected %q, got %q\n", expectedComment, rule.Comment)
+	}
+
+	got, ok := rule.Expiry()
+	if !ok || !got.Equal(expiry) {
+		t.Errorf("Expected %s, got %s (ok: %t)\n", expiry, got, ok)
+	}
+
+	rule.SetExpiry(time.Time{})
+	if rule.Comment != "Emergency block, see [INC-42]" {
+		t.Errorf("Expected expiry to be removed, got %q\n", rule.Comment)
+	}
+}
+
+// TestRuleSweeper ensures that only rules past their expiry and grace period are deleted
+func TestRuleSweeper(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"GET /rules": {http.StatusOK, `{"rules":[
+			{"id":"expired","comment":"[expires=2020-01-01T00:00:00Z]"},
+			{"id":"in-grace","comment":"Block [expires=2020-01-01T11:30:00Z]"},
+			{"id":"fresh","comment":"Block [expires=2020-01-02T00:00:00Z]"},
+			{"id":"permanent","comment":"Block"}
+		]}`},
+		"DELETE /rules/expired": {http.StatusOK, `{"acknowledged":true}`},
+	})
+	defer closeAPI()
+
+	sweeper := RuleSweeper{
+		Client:      client,
+		GracePeriod: time.Hour,
+		Clock:       fixedClock(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)),
+	}
+
+	report, err := sweeper.Sweep()
+	if err != nil {
+		t.Fatalf("Error sweeping rules: %s\n", err.Error())
+	}
+
+	if len(report.Removed) != 1 || report.Removed[0].ID != "expired" || len(report.Failed) != 0 {
+		t.Errorf("Expected only the expired rule to be removed, got %+v\n", report)
+	}
+
+	expectedRequests := []string{"GET /rules", "DELETE /rules/expired"}
+	if !reflect.DeepEqual(api.requests, expectedRequests) {
+		t.Errorf("Expected %v, got %v\n", expectedRequests, api.requests)
+	}
+}
+
+// TestCreateRuleWithTTL ensures that the expiry is computed from the client's clock
+func TestCreateRuleWithTTL(t *testing.T) {
+	client, api, closeAPI := newTestClient(t, map[string]mockResponse{
+		"POST /rules": {http.StatusOK, `{"id":"1"}`},
+	})
+	defer closeAPI()
+
+	WithClock(fixedClock(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)))(client)
+
+	if _, err := client.CreateRuleWithTTL(Rule{Comment: "Block"}, time.Hour); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	const expected = "Block [expires=2020-01-01T13:00:00Z]"
+	if len(api.bodies) != 1 || !strings.Contains(api.bodies[0], expected) {
+		t.Errorf("Expected %+v, got %+v\n", expected, api.bodies)
+	}
+}
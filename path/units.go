@@ -0,0 +1,209 @@
+package path
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// BPS is a traffic rate in bits per second
+type BPS uint64
+
+// PPS is a traffic rate in packets per second
+type PPS uint64
+
+// siPrefixes are the decimal prefixes used to format and parse rates, in increasing order
+var siPrefixes = []string{"", "K", "M", "G", "T", "P", "E"}
+
+// String formats the rate with the largest decimal prefix below it, e.g. "412.3 Gbps"
+func (rate BPS) String() string {
+	return formatRate(uint64(rate), "bps")
+}
+
+// String formats the rate with the largest decimal prefix below it, e.g. "38.1 Mpps"
+func (rate PPS) String() string {
+	return formatRate(uint64(rate), "pps")
+}
+
+// formatRate scales a rate to the largest decimal prefix below it. The value is compared once rounded to the decimal it
+// is formatted with, so that 999,950 bps is formatted as "1.0 Mbps" rather than "1000.0 Kbps"
+func formatRate(rate uint64, unit string) string {
+	value := float64(rate)
+
+	i := 0
+	for math.Round(value*10)/10 >= 1000 && i < len(siPrefixes)-1 {
+		value /= 1000
+		i++
+	}
+
+	if i == 0 {
+		return fmt.Sprintf("%d %s", rate, unit)
+	}
+
+	return fmt.Sprintf("%.1f %s%s", value, siPrefixes[i], unit)
+}
+
+// ParseBPS parses a bit rate such as "412.3 Gbps", "10G", "1.5 Tbit/s" or "1000". Prefixes are decimal and
+// case-insensitive
+func ParseBPS(value string) (BPS, error) {
+	rate, err := parseRate(value, []string{"bps", "b/s", "bit/s", "bits/s"})
+	return BPS(rate), err
+}
+
+// ParsePPS parses a packet rate such as "38.1 Mpps", "500k", "2 Mp/s" or "1000". Prefixes are decimal and
+// case-insensitive
+func ParsePPS(value string) (PPS, error) {
+	rate, err := parseRate(value, []string{"pps", "p/s", "packets/s"})
+	return PPS(rate), err
+}
+
+// parseRate parses a number followed by an optional decimal prefix and one of the given units
+func parseRate(value string, units []string) (uint64, error) {
+	text := strings.ToLower(strings.TrimSpace(value))
+
+	for _, unit := range units {
+		if strings.HasSuffix(text, unit) {
+			text = strings.TrimSpace(strings.TrimSuffix(text, unit))
+			break
+		}
+	}
+
+	multiplier := 1.0
+	for i := len(siPrefixes) - 1; i > 0; i-- {
+		if strings.HasSuffix(text, strings.ToLower(siPrefixes[i])) {
+			text = strings.TrimSpace(strings.TrimSuffix(text, strings.ToLower(siPrefixes[i])))
+			multiplier = math.Pow(1000, float64(i))
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil || number < 0 || math.IsInf(number, 0) || math.IsNaN(number) {
+		return 0, fmt.Errorf("invalid rate %q", value)
+	}
+
+	rate := math.Round(number * multiplier)
+	if rate >= math.MaxUint64 {
+		return 0, fmt.Errorf("rate %q is out of range", value)
+	}
+
+	return uint64(rate), nil
+}
+
+// Set parses the rate from a string, so that a *BPS can be used as a flag.Value
+func (rate *BPS) Set(value string) error {
+	parsed, err := ParseBPS(value)
+	if err != nil {
+		return err
+	}
+
+	*rate = parsed
+
+	return nil
+}
+
+// Set parses the rate from a string, so that a *PPS can be used as a flag.Value
+func (rate *PPS) Set(value string) error {
+	parsed, err := ParsePPS(value)
+	if err != nil {
+		return err
+	}
+
+	*rate = parsed
+
+	return nil
+}
+
+// UnmarshalText parses the rate from a string such as "10 Gbps"
+func (rate *BPS) UnmarshalText(text []byte) error {
+	return rate.Set(string(text))
+}
+
+// UnmarshalText parses the rate from a string such as "500 Kpps"
+func (rate *PPS) UnmarshalText(text []byte) error {
+	return rate.Set(string(text))
+}
+
+// UnmarshalJSON accepts a plain number, as returned by the API, or a string with units, as written in configuration
+// files
+func (rate *BPS) UnmarshalJSON(data []byte) error {
+	return unmarshalRate(data, rate.Set, (*uint64)(rate))
+}
+
+// UnmarshalJSON accepts a plain number, as returned by the API, or a string with units, as written in configuration
+// files
+func (rate *PPS) UnmarshalJSON(data []byte) error {
+	return unmarshalRate(data, rate.Set, (*uint64)(rate))
+}
+
+// unmarshalRate decodes a JSON string using set, and any other JSON value as a plain number
+func unmarshalRate(data []byte, set func(string) error, number *uint64) error {
+	data = bytes.TrimSpace(data)
+
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+
+		return set(text)
+	}
+
+	if string(data) == "null" {
+		return nil
+	}
+
+	return json.Unmarshal(data, number)
+}
+
+// MaxBPS returns the highest of the given rates, or zero if none are given
+func MaxBPS(rates ...BPS) BPS {
+	var highest BPS
+	for _, rate := range rates {
+		if rate > highest {
+			highest = rate
+		}
+	}
+
+	return highest
+}
+
+// MaxPPS returns the highest of the given rates, or zero if none are given
+func MaxPPS(rates ...PPS) PPS {
+	var highest PPS
+	for _, rate := range rates {
+		if rate > highest {
+			highest = rate
+		}
+	}
+
+	return highest
+}
+
+// Delta returns the signed difference from the other rate to this one, saturating at the bounds of an int64
+func (rate BPS) Delta(other BPS) int64 {
+	return rateDelta(uint64(rate), uint64(other))
+}
+
+// Delta returns the signed difference from the other rate to this one, saturating at the bounds of an int64
+func (rate PPS) Delta(other PPS) int64 {
+	return rateDelta(uint64(rate), uint64(other))
+}
+
+// rateDelta computes a - b without overflowing
+func rateDelta(a, b uint64) int64 {
+	if a >= b {
+		if a-b > math.MaxInt64 {
+			return math.MaxInt64
+		}
+		return int64(a - b)
+	}
+
+	if b-a > math.MaxInt64 {
+		return math.MinInt64
+	}
+	return -int64(b - a)
+}
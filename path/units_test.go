@@ -0,0 +1,130 @@
+package path
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// TestRateString ensures that rates are formatted with the largest decimal prefix below them
+func TestRateString(t *testing.T) {
+	cases := []struct {
+		rate     fmt.Stringer
+		expected string
+	}{
+		{BPS(412300000000), "412.3 Gbps"},
+		{PPS(38100000), "38.1 Mpps"},
+		{BPS(900), "900 bps"},
+		{BPS(1000), "1.0 Kbps"},
+		{BPS(999949), "999.9 Kbps"},
+		{BPS(999950), "1.0 Mbps"},
+		{PPS(999999), "1.0 Mpps"},
+		{BPS(999950000), "1.0 Gbps"},
+		{BPS(18446744073709551615), "18.4 Ebps"},
+	}
+
+	for _, c := range cases {
+		if got := c.rate.String(); got != c.expected {
+			t.Errorf("%d: expected %q, got %q\n", c.rate, c.expected, got)
+		}
+	}
+}
+
+// TestParseBPS ensures that bit rates are parsed with or without prefixes and units
+func TestParseBPS(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected BPS
+	}{
+		{"412.3 Gbps", 412300000000},
+		{"10G", 10000000000},
+		{"1.5 Tbit/s", 1500000000000},
+		{"1000", 1000},
+		{" 2 kb/s ", 2000},
+	}
+
+	for _, c := range cases {
+		got, err := ParseBPS(c.text)
+		if err != nil || got != c.expected {
+			t.Errorf("%q: expected %d, got %d (error: %v)\n", c.text, c.expected, got, err)
+		}
+	}
+
+	for _, text := range []string{"", "fast", "-1 Gbps", "10 Gpps", "100000 Ebps"} {
+		if _, err := ParseBPS(text); err == nil {
+			t.Errorf("%q: expected an error\n", text)
+		}
+	}
+}
+
+// TestParsePPS ensures that packet rates are parsed with or without prefixes and units
+func TestParsePPS(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected PPS
+	}{
+		{"38.1 Mpps", 38100000},
+		{"500k", 500000},
+		{"2 Mp/s", 2000000},
+	}
+
+	for _, c := range cases {
+		got, err := ParsePPS(c.text)
+		if err != nil || got != c.expected {
+			t.Errorf("%q: expected %d, got %d (error: %v)\n", c.text, c.expected, got, err)
+		}
+	}
+}
+
+// TestRateJSON ensures that rates are decoded from API numbers and configuration strings, and encoded as numbers
+func TestRateJSON(t *testing.T) {
+	var rateLimiter RateLimiter
+	if err := json.Unmarshal([]byte(`{"packets_per_second": "50 Kpps"}`), &rateLimiter); err != nil {
+		t.Fatalf("Error unmarshalling JSON into struct: %s\n", err.Error())
+	}
+
+	if rateLimiter.PacketsPerSecond != 50000 {
+		t.Errorf("Expected %+v, got %+v\n", 50000, rateLimiter.PacketsPerSecond)
+	}
+
+	var details AttackDetails
+	body := `{"peak_bps": {"value": 5000000000000}, "peak_pps": {"value": 400000000}}`
+	if err := json.Unmarshal([]byte(body), &details); err != nil {
+		t.Fatalf("Error unmarshalling JSON into struct: %s\n", err.Error())
+	}
+
+	if details.PeakBPS.Value != 5000000000000 {
+		t.Errorf("Expected %+v, got %+v\n", 5000000000000, details.PeakBPS.Value)
+	}
+
+	const expected = `{"packets_per_second":50000,"comment":""}`
+	encoded, err := json.Marshal(rateLimiter)
+	if err != nil {
+		t.Fatalf("Error marshalling struct into JSON: %s\n", err.Error())
+	}
+
+	if string(encoded) != expected {
+		t.Errorf("Expected %+v, got %+v\n", expected, string(encoded))
+	}
+}
+
+// TestRateComparisons ensures that peaks and rate limiters are compared against rates, and that deltas are signed
+func TestRateComparisons(t *testing.T) {
+	details := AttackDetails{PeakBPS: BPSPeak{Value: 5000000000000}, PeakPPS: PPSPeak{Value: 400000000}}
+	if !details.PeakAtLeast(1000000000000, 0) || details.PeakAtLeast(0, 1000000000) {
+		t.Errorf("Unexpected peak comparisons for %+v\n", details)
+	}
+
+	rateLimiter := RateLimiter{PacketsPerSecond: 50000}
+	if !rateLimiter.Admits(50000) || rateLimiter.Admits(50001) {
+		t.Errorf("Unexpected admissions for %+v\n", rateLimiter)
+	}
+
+	if got := BPS(1000).Delta(3000); got != -2000 {
+		t.Errorf("Expected %+v, got %+v\n", -2000, got)
+	}
+
+	if got := MaxPPS(3, 9, 4); got != 9 {
+		t.Errorf("Expected %+v, got %+v\n", 9, got)
+	}
+}